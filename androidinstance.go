@@ -40,8 +40,13 @@ func NewAndroidInstanceService(opts ...option.RequestOption) (r AndroidInstanceS
 }
 
 // Create an Android instance
+//
+// Every call is sent with a generated idempotency key, so the built-in retries on
+// network errors and 5xx responses never create more than one instance. Use
+// [option.WithIdempotencyKey] to provide your own key.
 func (r *AndroidInstanceService) New(ctx context.Context, params AndroidInstanceNewParams, opts ...option.RequestOption) (res *AndroidInstance, err error) {
 	opts = slices.Concat(r.Options, opts)
+	opts = append([]option.RequestOption{option.WithIdempotencyKey(requestconfig.NewIdempotencyKey())}, opts...)
 	path := "v1/android_instances"
 	err = requestconfig.ExecuteNewRequest(ctx, http.MethodPost, path, params, &res, opts...)
 	return
@@ -138,6 +138,64 @@ func TestOverwriteRetryCountHeader(t *testing.T) {
 	}
 }
 
+func TestIdempotencyKeyStableAcrossRetries(t *testing.T) {
+	idempotencyKeys := make([]string, 0)
+	client := limrun.NewClient(
+		option.WithAPIKey("My API Key"),
+		option.WithHTTPClient(&http.Client{
+			Transport: &closureTransport{
+				fn: func(req *http.Request) (*http.Response, error) {
+					idempotencyKeys = append(idempotencyKeys, req.Header.Get("Idempotency-Key"))
+					return &http.Response{
+						StatusCode: http.StatusServiceUnavailable,
+						Header: http.Header{
+							http.CanonicalHeaderKey("Retry-After"): []string{"0.1"},
+						},
+					}, nil
+				},
+			},
+		}),
+	)
+	_, err := client.IosInstances.New(context.Background(), limrun.IosInstanceNewParams{})
+	if err == nil {
+		t.Error("Expected there to be an error")
+	}
+
+	if len(idempotencyKeys) != 3 {
+		t.Fatalf("Expected %d attempts, got %d", 3, len(idempotencyKeys))
+	}
+	if idempotencyKeys[0] == "" {
+		t.Error("Expected an idempotency key to be generated")
+	}
+	for _, key := range idempotencyKeys {
+		if key != idempotencyKeys[0] {
+			t.Errorf("Expected the same idempotency key on every attempt, got %v", idempotencyKeys)
+			break
+		}
+	}
+}
+
+func TestOverwriteIdempotencyKey(t *testing.T) {
+	idempotencyKey := ""
+	client := limrun.NewClient(
+		option.WithAPIKey("My API Key"),
+		option.WithHTTPClient(&http.Client{
+			Transport: &closureTransport{
+				fn: func(req *http.Request) (*http.Response, error) {
+					idempotencyKey = req.Header.Get("Idempotency-Key")
+					return &http.Response{
+						StatusCode: http.StatusOK,
+					}, nil
+				},
+			},
+		}),
+	)
+	client.AndroidInstances.New(context.Background(), limrun.AndroidInstanceNewParams{}, option.WithIdempotencyKey("my-key"))
+	if idempotencyKey != "my-key" {
+		t.Errorf("Expected Idempotency-Key to be %q, got %q", "my-key", idempotencyKey)
+	}
+}
+
 func TestRetryAfterMs(t *testing.T) {
 	attempts := 0
 	client := limrun.NewClient(
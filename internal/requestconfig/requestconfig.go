@@ -5,6 +5,8 @@ package requestconfig
 import (
 	"bytes"
 	"context"
+	crand "crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -30,6 +32,16 @@ func getDefaultHeaders() map[string]string {
 	}
 }
 
+// IdempotencyHeader is the header the server uses to deduplicate retried requests.
+const IdempotencyHeader = "Idempotency-Key"
+
+// NewIdempotencyKey returns a random key to be sent in the [IdempotencyHeader].
+func NewIdempotencyKey() string {
+	b := make([]byte, 16)
+	_, _ = crand.Read(b)
+	return "go-retry-" + hex.EncodeToString(b)
+}
+
 func getNormalizedOS() string {
 	switch runtime.GOOS {
 	case "ios":
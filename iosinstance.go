@@ -40,8 +40,13 @@ func NewIosInstanceService(opts ...option.RequestOption) (r IosInstanceService)
 }
 
 // Create an iOS instance
+//
+// Every call is sent with a generated idempotency key, so the built-in retries on
+// network errors and 5xx responses never create more than one instance. Use
+// [option.WithIdempotencyKey] to provide your own key.
 func (r *IosInstanceService) New(ctx context.Context, params IosInstanceNewParams, opts ...option.RequestOption) (res *IosInstance, err error) {
 	opts = slices.Concat(r.Options, opts)
+	opts = append([]option.RequestOption{option.WithIdempotencyKey(requestconfig.NewIdempotencyKey())}, opts...)
 	path := "v1/ios_instances"
 	err = requestconfig.ExecuteNewRequest(ctx, http.MethodPost, path, params, &res, opts...)
 	return
@@ -0,0 +1,75 @@
+package limrun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/limrun-inc/go-sdk/internal/requestconfig"
+	"github.com/limrun-inc/go-sdk/option"
+)
+
+// ExposedPort is a port of an instance that can be forwarded with a tunnel.
+type ExposedPort struct {
+	// Port is the port number on the instance.
+	Port int `json:"port"`
+	// Name describes what the port is used for, e.g. "webdriveragent".
+	Name string `json:"name"`
+}
+
+// Ports returns the ports exposed by the iOS instance with given ID. Each of them can be forwarded
+// with a tunnel, see tunnel.ForwardAll.
+func (r *IosInstanceService) Ports(ctx context.Context, id string, opts ...option.RequestOption) (res []ExposedPort, err error) {
+	opts = slices.Concat(r.Options, opts)
+	if id == "" {
+		err = errors.New("missing required id parameter")
+		return
+	}
+	path := fmt.Sprintf("v1/ios_instances/%s/ports", id)
+	err = requestconfig.ExecuteNewRequest(ctx, http.MethodGet, path, nil, &res, opts...)
+	return
+}
+
+// defaultWaitForReadyInterval is how often WaitForReady polls the instance.
+const defaultWaitForReadyInterval = 2 * time.Second
+
+// WaitForReady polls the iOS instance with given ID until it's ready and returns it with its Status
+// populated. It returns an error if the instance is terminated or ctx is done first.
+//
+// Instances created without the Wait parameter may not be ready yet, call WaitForReady before
+// connecting to them.
+func (r *IosInstanceService) WaitForReady(ctx context.Context, id string, opts ...option.RequestOption) (*IosInstance, error) {
+	return r.WaitForReadyWithInterval(ctx, id, defaultWaitForReadyInterval, opts...)
+}
+
+// WaitForReadyWithInterval is like WaitForReady but polls every interval.
+func (r *IosInstanceService) WaitForReadyWithInterval(ctx context.Context, id string, interval time.Duration, opts ...option.RequestOption) (*IosInstance, error) {
+	if interval <= 0 {
+		interval = defaultWaitForReadyInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		instance, err := r.Get(ctx, id, opts...)
+		if err != nil {
+			return nil, err
+		}
+		switch instance.Status.State {
+		case "ready":
+			return instance, nil
+		case "terminated":
+			if instance.Status.ErrorMessage != "" {
+				return nil, fmt.Errorf("ios instance %s is terminated: %s", id, instance.Status.ErrorMessage)
+			}
+			return nil, fmt.Errorf("ios instance %s is terminated", id)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("waiting for ios instance %s to be ready: %w", id, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
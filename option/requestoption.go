@@ -115,6 +115,15 @@ func WithHeader(key, value string) RequestOption {
 	})
 }
 
+// WithIdempotencyKey returns a RequestOption that sets the Idempotency-Key header. Requests
+// sent with the same key are only executed once by the server, so retrying them is safe.
+//
+// Create calls, like IosInstances.New and AndroidInstances.New, generate a key per call
+// automatically; use this option to supply your own, e.g. to retry across processes.
+func WithIdempotencyKey(key string) RequestOption {
+	return WithHeader(requestconfig.IdempotencyHeader, key)
+}
+
 // WithHeaderAdd returns a RequestOption that adds the header value to the associated key. It appends
 // onto any existing values.
 func WithHeaderAdd(key, value string) RequestOption {
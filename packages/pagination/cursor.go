@@ -0,0 +1,161 @@
+package pagination
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/limrun-inc/go-sdk/internal/apijson"
+	"github.com/limrun-inc/go-sdk/internal/requestconfig"
+	"github.com/limrun-inc/go-sdk/option"
+	"github.com/limrun-inc/go-sdk/packages/respjson"
+)
+
+// defaultCursorParam is the query parameter the cursor is sent in unless configured otherwise.
+const defaultCursorParam = "startingAfter"
+
+// CursorPage is a page of a list whose next page is requested with a cursor derived from the
+// last item of the page. By default the cursor is the ID of the last item, or the ID in its
+// Metadata, sent in the "startingAfter" query parameter; use SetCursor to change that for a
+// resource. Pages fetched with GetNextPage keep the configuration.
+type CursorPage[T any] struct {
+	Items []T `json:",inline"`
+	// JSON contains metadata for fields, check presence with [respjson.Field.Valid].
+	JSON struct {
+		Items       respjson.Field
+		ExtraFields map[string]respjson.Field
+		raw         string
+	} `json:"-"`
+	cfg *requestconfig.RequestConfig
+	res *http.Response
+
+	cursorParam string
+	cursor      func(item T) (string, error)
+}
+
+// Returns the unmodified JSON received from the API
+func (r CursorPage[T]) RawJSON() string { return r.JSON.raw }
+func (r *CursorPage[T]) UnmarshalJSON(data []byte) error {
+	return apijson.UnmarshalRoot(data, r)
+}
+
+// SetCursor sets the query parameter the cursor is sent in and the function that returns the
+// cursor for the page after the given last item. Empty or nil values keep the defaults.
+func (r *CursorPage[T]) SetCursor(param string, cursor func(item T) (string, error)) {
+	r.cursorParam = param
+	r.cursor = cursor
+}
+
+// GetNextPage returns the next page as defined by this pagination style. When
+// there is no next page, this function will return a 'nil' for the page value, but
+// will not return an error
+func (r *CursorPage[T]) GetNextPage() (res *CursorPage[T], err error) {
+	if len(r.Items) == 0 {
+		return nil, nil
+	}
+	cursor, err := r.nextCursor(r.Items[len(r.Items)-1])
+	if err != nil {
+		return nil, err
+	}
+	param := r.cursorParam
+	if param == "" {
+		param = defaultCursorParam
+	}
+	cfg := r.cfg.Clone(r.cfg.Context)
+	err = cfg.Apply(option.WithQuery(param, cursor))
+	if err != nil {
+		return nil, err
+	}
+	var raw *http.Response
+	cfg.ResponseInto = &raw
+	cfg.ResponseBodyInto = &res
+	err = cfg.Execute()
+	if err != nil {
+		return nil, err
+	}
+	res.SetPageConfig(cfg, raw)
+	res.SetCursor(r.cursorParam, r.cursor)
+	return res, nil
+}
+
+func (r *CursorPage[T]) nextCursor(last T) (string, error) {
+	if r.cursor != nil {
+		return r.cursor(last)
+	}
+	return idCursor(last)
+}
+
+// idCursor returns the ID field of item, or the ID field of its Metadata field.
+func idCursor(item any) (string, error) {
+	value := reflect.ValueOf(item)
+	if value.Kind() != reflect.Struct {
+		return "", fmt.Errorf("pagination: cannot get the ID of %T to request the next page", item)
+	}
+	field := value.FieldByName("ID")
+	if !field.IsValid() {
+		// Instances carry their ID in their metadata.
+		if metadata := value.FieldByName("Metadata"); metadata.Kind() == reflect.Struct {
+			field = metadata.FieldByName("ID")
+		}
+	}
+	if !field.IsValid() {
+		return "", fmt.Errorf("pagination: %T has no ID field to request the next page with", item)
+	}
+	if field.Kind() != reflect.String {
+		return "", fmt.Errorf("pagination: ID field of %T is a %s, not a string", item, field.Type())
+	}
+	return field.String(), nil
+}
+
+func (r *CursorPage[T]) SetPageConfig(cfg *requestconfig.RequestConfig, res *http.Response) {
+	if r == nil {
+		r = &CursorPage[T]{}
+	}
+	r.cfg = cfg
+	r.res = res
+}
+
+type CursorPageAutoPager[T any] struct {
+	page *CursorPage[T]
+	cur  T
+	idx  int
+	run  int
+	err  error
+	paramObj
+}
+
+func NewCursorPageAutoPager[T any](page *CursorPage[T], err error) *CursorPageAutoPager[T] {
+	return &CursorPageAutoPager[T]{
+		page: page,
+		err:  err,
+	}
+}
+
+func (r *CursorPageAutoPager[T]) Next() bool {
+	if r.page == nil || len(r.page.Items) == 0 {
+		return false
+	}
+	if r.idx >= len(r.page.Items) {
+		r.idx = 0
+		r.page, r.err = r.page.GetNextPage()
+		if r.err != nil || r.page == nil || len(r.page.Items) == 0 {
+			return false
+		}
+	}
+	r.cur = r.page.Items[r.idx]
+	r.run += 1
+	r.idx += 1
+	return true
+}
+
+func (r *CursorPageAutoPager[T]) Current() T {
+	return r.cur
+}
+
+func (r *CursorPageAutoPager[T]) Err() error {
+	return r.err
+}
+
+func (r *CursorPageAutoPager[T]) Index() int {
+	return r.run
+}
@@ -0,0 +1,63 @@
+package pagination_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/limrun-inc/go-sdk/packages/pagination"
+)
+
+func TestGetNextPageWithoutID(t *testing.T) {
+	type noID struct {
+		Name string
+	}
+	type intID struct {
+		ID int
+	}
+	type metadataID struct {
+		Metadata struct {
+			ID int
+		}
+	}
+	tests := map[string]struct {
+		next    func() error
+		message string
+	}{
+		"missing": {
+			next: func() error {
+				_, err := (&pagination.Items[noID]{Items: []noID{{Name: "a"}}}).GetNextPage()
+				return err
+			},
+			message: "has no ID field",
+		},
+		"not a string": {
+			next: func() error {
+				_, err := (&pagination.Items[intID]{Items: []intID{{ID: 1}}}).GetNextPage()
+				return err
+			},
+			message: "not a string",
+		},
+		"metadata not a string": {
+			next: func() error {
+				_, err := (&pagination.Items[metadataID]{Items: []metadataID{{}}}).GetNextPage()
+				return err
+			},
+			message: "not a string",
+		},
+		"not a struct": {
+			next: func() error {
+				_, err := (&pagination.CursorPage[string]{Items: []string{"a"}}).GetNextPage()
+				return err
+			},
+			message: "cannot get the ID",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := test.next()
+			if err == nil || !strings.Contains(err.Error(), test.message) {
+				t.Fatalf("expected an error containing %q, got %v", test.message, err)
+			}
+		})
+	}
+}
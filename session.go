@@ -0,0 +1,122 @@
+package limrun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+
+	"github.com/limrun-inc/go-sdk/option"
+	"github.com/limrun-inc/go-sdk/packages/param"
+	"github.com/limrun-inc/go-sdk/tunnel"
+	"github.com/limrun-inc/go-sdk/websocket/ios"
+)
+
+// IosSession ties together an iOS instance, the WebSocket client used to control it and any tunnels opened to it
+// so that all of them can be cleaned up with a single Close call.
+type IosSession struct {
+	// Instance is the iOS instance this session is connected to.
+	Instance *IosInstance
+
+	client  *Client
+	opts    []option.RequestOption
+	control *ios.Client
+
+	mu      sync.Mutex
+	tunnels []*tunnel.Multiplexed
+	closed  bool
+}
+
+// NewIosSession creates an iOS instance with the given params, waits for it to be ready and connects to it.
+//
+// The Wait parameter is always set since the session needs a ready instance to connect to. If connecting fails,
+// the instance is deleted before returning the error.
+//
+// Call Close when you're done to close all connections and delete the instance.
+func NewIosSession(ctx context.Context, client *Client, params IosInstanceNewParams, opts ...option.RequestOption) (*IosSession, error) {
+	params.Wait = param.NewOpt(true)
+	instance, err := client.IosInstances.New(ctx, params, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ios instance: %w", err)
+	}
+	s := &IosSession{
+		Instance: instance,
+		client:   client,
+		opts:     opts,
+	}
+	control, err := ios.NewClient(instance.Status.APIURL, instance.Status.Token)
+	if err != nil {
+		if delErr := s.deleteInstance(); delErr != nil {
+			return nil, errors.Join(fmt.Errorf("failed to connect to ios instance: %w", err), delErr)
+		}
+		return nil, fmt.Errorf("failed to connect to ios instance: %w", err)
+	}
+	s.control = control
+	return s, nil
+}
+
+// Control returns the WebSocket client connected to the instance.
+func (s *IosSession) Control() *ios.Client {
+	return s.control
+}
+
+// Tunnel starts a Multiplexed tunnel to the given port of the instance. The tunnel is closed when the session is
+// closed. Use Addr of the returned tunnel to get the local address to connect to.
+func (s *IosSession) Tunnel(port int) (*tunnel.Multiplexed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, errors.New("session is closed")
+	}
+	u, err := url.Parse(s.Instance.Status.EndpointWebSocketURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint websocket url: %w", err)
+	}
+	t, err := tunnel.NewMultiplexed(u, port, s.Instance.Status.Token)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.Start(); err != nil {
+		_ = t.Close()
+		return nil, err
+	}
+	s.tunnels = append(s.tunnels, t)
+	return t, nil
+}
+
+// Close closes all tunnels and the control connection, then deletes the instance.
+func (s *IosSession) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	tunnels := s.tunnels
+	s.tunnels = nil
+	s.mu.Unlock()
+
+	var errs []error
+	for _, t := range tunnels {
+		if err := t.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("closing tunnel: %w", err))
+		}
+	}
+	if s.control != nil {
+		if err := s.control.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("closing control connection: %w", err))
+		}
+	}
+	if err := s.deleteInstance(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func (s *IosSession) deleteInstance() error {
+	if err := s.client.IosInstances.Delete(context.Background(), s.Instance.Metadata.ID, s.opts...); err != nil {
+		return fmt.Errorf("failed to delete ios instance: %w", err)
+	}
+	return nil
+}
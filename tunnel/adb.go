@@ -2,6 +2,7 @@ package tunnel
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -20,6 +21,40 @@ func WithADBPath(p string) Option {
 	}
 }
 
+// defaultADBPingInterval is the default interval between WebSocket pings of an ADB tunnel.
+const defaultADBPingInterval = 30 * time.Second
+
+// WithADBPingInterval sets how often the WebSocket connections are pinged to keep them alive,
+// e.g. more often than the idle timeout of a load balancer in between. Defaults to 30 seconds; a
+// non-positive value keeps the default.
+func WithADBPingInterval(d time.Duration) Option {
+	return func(t *ADB) {
+		if d <= 0 {
+			d = defaultADBPingInterval
+		}
+		t.pingInterval = d
+	}
+}
+
+// WithADBDialer sets the dialer used to connect to the remote WebSocket server, e.g. to configure
+// TLS for a dev server with a self-signed certificate, a handshake timeout or a proxy. Defaults to
+// websocket.DefaultDialer.
+func WithADBDialer(d *websocket.Dialer) Option {
+	return func(t *ADB) {
+		if d != nil {
+			t.dialer = d
+		}
+	}
+}
+
+// WithoutAutoConnect makes Start only bring up the tunnel without running "adb connect", so the
+// adb executable isn't needed. Connect to Addr yourself, e.g. if you manage ADB on your own.
+func WithoutAutoConnect() Option {
+	return func(t *ADB) {
+		t.noAutoConnect = true
+	}
+}
+
 type Option func(*ADB)
 
 // NewADB returns a new ADB that will listen on an available port and converts ADB traffic into WebSocket.
@@ -29,10 +64,13 @@ func NewADB(remoteURL, token string, opts ...Option) (*ADB, error) {
 		return nil, fmt.Errorf("creating a tcp listener failed: %w", err)
 	}
 	t := &ADB{
-		RemoteURL: remoteURL,
-		Token:     token,
-		ADBPath:   "adb",
-		listener:  listener,
+		RemoteURL:    remoteURL,
+		Token:        token,
+		ADBPath:      "adb",
+		listener:     listener,
+		errs:         newErrorReporter(),
+		pingInterval: defaultADBPingInterval,
+		dialer:       websocket.DefaultDialer,
 	}
 	for _, f := range opts {
 		f(t)
@@ -52,27 +90,54 @@ type ADB struct {
 	// ADBPath is the path to adb executable. Defaults to just "adb".
 	ADBPath string
 
-	listener net.Listener
-	cancel   context.CancelCauseFunc
+	listener      net.Listener
+	cancel        context.CancelCauseFunc
+	errs          errorReporter
+	noAutoConnect bool
+	pingInterval  time.Duration
+	dialer        *websocket.Dialer
 }
 
 // Start starts a tunnel to the Android instance through the given URL and notifies the local ADB to recognize
-// it.
+// it, unless WithoutAutoConnect is given.
 // It is non-blocking and continues to run in the background.
 // Call Close() method of the returned ADB to make sure it's properly cleaned up.
 func (t *ADB) Start() error {
+	return t.StartContext(context.Background())
+}
+
+// StartContext is like Start but the tunnel is tied to ctx: cancelling it stops the tunnel, as
+// does Close, and aborts "adb connect" if it's still running.
+//
+// The local listener is bound before "adb connect" runs so that adb can always reach it.
+func (t *ADB) StartContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	tCtx, cancel := context.WithCancelCause(ctx)
+	t.cancel = cancel
 	go func() {
-		if err := t.startTunnel(); err != nil {
-			log.Printf("failed to start TCP tunnel: %s", err)
+		if err := t.startTunnel(tCtx, cancel); err != nil && !errors.Is(err, context.Canceled) {
+			t.errs.report(fmt.Errorf("failed to start TCP tunnel: %w", err))
 		}
 	}()
-	out, err := exec.CommandContext(context.Background(), t.ADBPath, "connect", t.Addr()).CombinedOutput()
+	if t.noAutoConnect {
+		return nil
+	}
+	out, err := exec.CommandContext(tCtx, t.ADBPath, "connect", t.Addr()).CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("failed to connect adb: %w %s", err, string(out))
 	}
 	return nil
 }
 
+// Errors returns a channel that receives errors that break the tunnel or one of its forwarded
+// connections, e.g. a failed dial, read or ping, so that programs can react to them. Errors are logged instead if Errors
+// was never called or the receiver falls behind. The channel is never closed.
+func (t *ADB) Errors() <-chan error {
+	return t.errs.channel()
+}
+
 func (t *ADB) Addr() string {
 	return fmt.Sprintf("127.0.0.1:%d", t.listener.Addr().(*net.TCPAddr).Port)
 }
@@ -84,30 +149,46 @@ func (t *ADB) Close() {
 	}
 }
 
-// startTunnel starts the local ADB server to forward to WebSocket.
-// Blocks until connection is closed.
+// startTunnel starts the local ADB server to forward to WebSocket. Every accepted TCP connection
+// is forwarded over its own WebSocket connection so that concurrent adb sessions, e.g. an
+// install while logcat runs, don't block each other.
+// Blocks until tCtx is done or accepting fails, and calls cancel before returning.
 // Cancel the context or call Close() when you'd like to stop this tunnel.
-//
-// You can optionally provide ready channel so that tunnel sends "true" when it's ready to accept connections,
-// e.g. you can call "adb connect" after that message.
-func (t *ADB) startTunnel() error {
-	tCtx, cancel := context.WithCancelCause(context.Background())
-	t.cancel = cancel
+func (t *ADB) startTunnel(tCtx context.Context, cancel context.CancelCauseFunc) error {
 	defer cancel(nil)
 
-	defer func() {
+	// Closing the listener unblocks Accept when the tunnel is stopped.
+	go func() {
+		<-tCtx.Done()
 		_ = t.listener.Close()
 	}()
 
-	tcpConn, err := t.listener.Accept()
-	if err != nil {
-		return fmt.Errorf("failed to accept connection: %w", err)
+	for {
+		tcpConn, err := t.listener.Accept()
+		if err != nil {
+			if tCtx.Err() != nil {
+				return context.Cause(tCtx)
+			}
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+		go func() {
+			if err := t.handleConnection(tCtx, tcpConn); err != nil && !errors.Is(err, context.Canceled) {
+				t.errs.report(err)
+			}
+		}()
 	}
+}
+
+// handleConnection forwards a single TCP connection over a new WebSocket connection.
+// Blocks until either side of the connection is closed or tCtx is done.
+func (t *ADB) handleConnection(tCtx context.Context, tcpConn net.Conn) error {
 	defer func() {
 		_ = tcpConn.Close()
 	}()
+	cCtx, cancel := context.WithCancelCause(tCtx)
+	defer cancel(nil)
 
-	ws, _, err := websocket.DefaultDialer.Dial(t.RemoteURL, http.Header{
+	ws, _, err := t.dialer.DialContext(cCtx, t.RemoteURL, http.Header{
 		"Authorization": []string{fmt.Sprintf("Bearer %s", t.Token)},
 	})
 	if err != nil {
@@ -118,14 +199,14 @@ func (t *ADB) startTunnel() error {
 	}()
 
 	go func() {
-		ticker := time.NewTicker(30 * time.Second)
+		ticker := time.NewTicker(t.pingInterval)
 		defer ticker.Stop()
 		for {
 			select {
-			case <-tCtx.Done():
+			case <-cCtx.Done():
 				return
 			case <-ticker.C:
-				if err := ws.WriteControl(websocket.PingMessage, []byte{}, time.Now().Add(10*time.Second)); err != nil {
+				if err := ws.WriteControl(websocket.PingMessage, []byte{}, time.Now().Add(pingWriteTimeout)); err != nil {
 					cancel(fmt.Errorf("ping failed: %v", err))
 					return
 				}
@@ -138,7 +219,7 @@ func (t *ADB) startTunnel() error {
 		buffer := make([]byte, 32*1024)
 		for {
 			select {
-			case <-tCtx.Done():
+			case <-cCtx.Done():
 				return
 			default:
 			}
@@ -149,6 +230,7 @@ func (t *ADB) startTunnel() error {
 					cancel(fmt.Errorf("failed to read from tcp: %w", err))
 				} else {
 					log.Printf("tcp->ws: TCP connection closed by client")
+					cancel(nil)
 				}
 				return
 			}
@@ -166,7 +248,7 @@ func (t *ADB) startTunnel() error {
 	go func() {
 		for {
 			select {
-			case <-tCtx.Done():
+			case <-cCtx.Done():
 				return
 			default:
 			}
@@ -184,6 +266,6 @@ func (t *ADB) startTunnel() error {
 			}
 		}
 	}()
-	<-tCtx.Done()
-	return context.Cause(tCtx)
+	<-cCtx.Done()
+	return context.Cause(cCtx)
 }
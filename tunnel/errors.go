@@ -0,0 +1,36 @@
+package tunnel
+
+import (
+	"log"
+	"sync/atomic"
+)
+
+// errorBufferSize is the number of errors buffered for a slow receiver of Errors.
+const errorBufferSize = 16
+
+// errorReporter delivers runtime errors of a tunnel to the channel returned by Errors, or logs
+// them if nobody asked for the channel or its buffer is full.
+type errorReporter struct {
+	ch       chan error
+	consumed atomic.Bool
+}
+
+func newErrorReporter() errorReporter {
+	return errorReporter{ch: make(chan error, errorBufferSize)}
+}
+
+func (r *errorReporter) channel() <-chan error {
+	r.consumed.Store(true)
+	return r.ch
+}
+
+func (r *errorReporter) report(err error) {
+	if r.consumed.Load() {
+		select {
+		case r.ch <- err:
+			return
+		default:
+		}
+	}
+	log.Printf("%v", err)
+}
@@ -0,0 +1,38 @@
+package tunnel
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// ForwardAll starts a Multiplexed tunnel for each of the given remote ports and returns them keyed
+// by the remote port. Use it together with the list of ports an instance exposes to forward all
+// of them without hardcoding port numbers.
+//
+// The options are applied to every tunnel, so MultiplexedWithLocalPort must not be used here.
+// If any of the tunnels fails to start, the ones already started are closed.
+// Call Close() on every returned tunnel when you're done.
+func ForwardAll(remoteURL *url.URL, token string, ports []int, opts ...MultiplexedOption) (map[int]*Multiplexed, error) {
+	tunnels := make(map[int]*Multiplexed, len(ports))
+	closeAll := func() {
+		for _, t := range tunnels {
+			_ = t.Close()
+		}
+	}
+	for _, port := range ports {
+		if _, ok := tunnels[port]; ok {
+			continue
+		}
+		t, err := NewMultiplexed(remoteURL, port, token, opts...)
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("failed to create tunnel for port %d: %w", port, err)
+		}
+		tunnels[port] = t
+		if err := t.Start(); err != nil {
+			closeAll()
+			return nil, fmt.Errorf("failed to start tunnel for port %d: %w", port, err)
+		}
+	}
+	return tunnels, nil
+}
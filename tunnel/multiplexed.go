@@ -1,7 +1,9 @@
 package tunnel
 
 import (
+	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -40,9 +42,24 @@ import (
 // with only 4 bytes of overhead per message.
 
 const (
-	connIDSize = 4 // Size of connection ID in bytes
+	connIDSize        = 4         // Size of connection ID in bytes
+	defaultBufferSize = 32 * 1024 // Default size of the per-connection read buffer
+
+	// connWriteQueueSize is the number of messages from the remote queued for a TCP connection.
+	// Each connection is written to by its own goroutine so that a slow peer doesn't hold up the
+	// other connections; a peer that falls this far behind is closed.
+	connWriteQueueSize = 64
+
+	defaultMultiplexedPingInterval = 10 * time.Second // Default interval between WebSocket pings
+	pingWriteTimeout               = 10 * time.Second // Deadline for writing a single ping
+
+	shutdownPollInterval = 50 * time.Millisecond // How often Shutdown checks for drained connections
+	forceCloseTimeout    = time.Second           // How long Shutdown waits for force-closed connections
 )
 
+// errWebSocketDown is returned when writing to a WebSocket connection that dropped.
+var errWebSocketDown = errors.New("websocket connection is down")
+
 // encodeMessage creates a WebSocket message by prefixing data with connection ID.
 // Format: [4 bytes: connID][data]
 func encodeMessage(connID uint32, data []byte) []byte {
@@ -70,6 +87,114 @@ func MultiplexedWithLocalPort(port int) MultiplexedOption {
 	}
 }
 
+// MultiplexedWithBindAddress sets the host or IP address the local TCP listener binds to, e.g.
+// "127.0.0.1" to accept only local connections or "0.0.0.0" to share the forward with other
+// machines. If not given, the listener binds to all interfaces.
+//
+// Anyone who can reach a non-loopback address can use the tunnel, and with it the remote
+// instance, without knowing the token.
+func MultiplexedWithBindAddress(addr string) MultiplexedOption {
+	return func(r *Multiplexed) {
+		r.bindAddress = addr
+	}
+}
+
+// MultiplexedWithUnknownConnectionHandler sets a function to be called when a message arrives for
+// a connection ID that is not known, e.g. because the server keeps sending on a connection that
+// was already closed. hasData is false for close signals, which are expected after a close.
+// If not given, messages with data are logged and close signals are ignored.
+func MultiplexedWithUnknownConnectionHandler(f func(connID uint32, hasData bool)) MultiplexedOption {
+	return func(r *Multiplexed) {
+		r.onUnknownConnection = f
+	}
+}
+
+// MultiplexedWithReconnect makes the tunnel re-dial the WebSocket connection when it drops
+// unexpectedly. Up to maxRetries attempts are made, waiting backoff before the first one and
+// doubling the wait after every failed attempt. If all attempts fail, the tunnel is closed.
+//
+// TCP connections that were forwarded over the dropped WebSocket connection are closed since
+// their remote side is gone; new connections use the new WebSocket connection.
+func MultiplexedWithReconnect(maxRetries int, backoff time.Duration) MultiplexedOption {
+	return func(r *Multiplexed) {
+		r.reconnectRetries = maxRetries
+		r.reconnectBackoff = backoff
+	}
+}
+
+// MultiplexedWithBufferSize sets the size of the buffer used to read from each forwarded TCP
+// connection, which is also the maximum size of the data in a single WebSocket message.
+// Larger buffers mean fewer messages for high-throughput transfers but every concurrent
+// connection allocates its own buffer. Defaults to 32KB; a non-positive value keeps the default.
+func MultiplexedWithBufferSize(n int) MultiplexedOption {
+	return func(r *Multiplexed) {
+		if n <= 0 {
+			n = defaultBufferSize
+		}
+		r.bufferSize = n
+	}
+}
+
+// MultiplexedWithIdleTimeout closes a forwarded TCP connection, and tells the remote to close its
+// side, if no bytes flow in either direction for d. Zero, the default, disables the timeout.
+//
+// In UDP mode, it sets how long a source address is remembered without datagrams from or to it,
+// which defaults to two minutes.
+func MultiplexedWithIdleTimeout(d time.Duration) MultiplexedOption {
+	return func(r *Multiplexed) {
+		r.idleTimeout = d
+	}
+}
+
+// MultiplexedWithPingInterval sets how often the WebSocket connection is pinged to keep it alive,
+// e.g. more often than the idle timeout of a load balancer in between. Defaults to 10 seconds; a
+// non-positive value keeps the default.
+func MultiplexedWithPingInterval(d time.Duration) MultiplexedOption {
+	return func(r *Multiplexed) {
+		if d <= 0 {
+			d = defaultMultiplexedPingInterval
+		}
+		r.pingInterval = d
+	}
+}
+
+// MultiplexedWithDialer sets the dialer used to connect to the remote WebSocket server, e.g. to
+// configure TLS for a dev server with a self-signed certificate, a handshake timeout or a proxy.
+// Defaults to websocket.DefaultDialer.
+func MultiplexedWithDialer(d *websocket.Dialer) MultiplexedOption {
+	return func(r *Multiplexed) {
+		if d != nil {
+			r.dialer = d
+		}
+	}
+}
+
+// MultiplexedWithReadTimeout closes a forwarded TCP connection, and tells the remote to close its
+// side, if the local peer sends nothing for d, regardless of data from the remote. Writes to the
+// peer that block for d fail and close the connection too. Unlike MultiplexedWithIdleTimeout,
+// this reaps peers that are gone without closing the socket. Zero, the default, disables the
+// timeout.
+func MultiplexedWithReadTimeout(d time.Duration) MultiplexedOption {
+	return func(r *Multiplexed) {
+		r.readTimeout = d
+	}
+}
+
+// MultiplexedWithMaxConnections limits the number of TCP connections forwarded at the same time
+// to n. Once the limit is reached, new connections aren't accepted until an active one closes, so
+// they wait in the listener's backlog. Zero, the default, means no limit.
+//
+// In UDP mode, it limits the number of source addresses instead; datagrams from new sources are
+// dropped while the limit is reached.
+func MultiplexedWithMaxConnections(n int) MultiplexedOption {
+	return func(r *Multiplexed) {
+		r.slots = nil
+		if n > 0 {
+			r.slots = make(chan struct{}, n)
+		}
+	}
+}
+
 type MultiplexedOption func(*Multiplexed)
 
 // NewMultiplexed returns a new Multiplexed tunnel.
@@ -79,21 +204,41 @@ func NewMultiplexed(remoteURL *url.URL, remotePort int, token string, opts ...Mu
 	u := remoteURL.JoinPath()
 	u.RawQuery = q.Encode()
 	t := &Multiplexed{
-		RemoteURL: u,
-		Token:     token,
+		RemoteURL:    u,
+		Token:        token,
+		bufferSize:   defaultBufferSize,
+		pingInterval: defaultMultiplexedPingInterval,
+		dialer:       websocket.DefaultDialer,
+		ready:        make(chan struct{}),
+		startErr:     make(chan error, 1),
+		errs:         newErrorReporter(),
+		done:         make(chan struct{}),
+		network:      "tcp",
 	}
 	for _, f := range opts {
 		f(t)
 	}
-	localPort := ":0"
+	localPort := "0"
 	if t.LocalPort != nil {
-		localPort = fmt.Sprintf(":%d", *t.LocalPort)
+		localPort = strconv.Itoa(*t.LocalPort)
 	}
-	listener, err := net.Listen("tcp", localPort)
-	if err != nil {
-		return nil, fmt.Errorf("creating a tcp listener failed: %w", err)
+	addr := net.JoinHostPort(t.bindAddress, localPort)
+	switch {
+	case t.isUDP():
+		packetConn, err := net.ListenPacket(t.network, addr)
+		if err != nil {
+			return nil, fmt.Errorf("creating a udp listener failed: %w", err)
+		}
+		t.packetConn = packetConn
+	case t.network == "tcp" || t.network == "tcp4" || t.network == "tcp6":
+		listener, err := net.Listen(t.network, addr)
+		if err != nil {
+			return nil, fmt.Errorf("creating a tcp listener failed: %w", err)
+		}
+		t.listener = listener
+	default:
+		return nil, fmt.Errorf("unsupported network %q", t.network)
 	}
-	t.listener = listener
 	return t, nil
 }
 
@@ -113,13 +258,67 @@ type Multiplexed struct {
 	// if it's marked as revoked.
 	Token string
 
-	listener net.Listener
+	listener    net.Listener
+	packetConn  net.PacketConn // used instead of listener in UDP mode
+	bindAddress string
+	network     string
+
+	onUnknownConnection func(connID uint32, hasData bool)
+	reconnectRetries    int
+	reconnectBackoff    time.Duration
+	bufferSize          int
+	idleTimeout         time.Duration
+	readTimeout         time.Duration
+	slots               chan struct{} // limits concurrent connections if not nil
+	pingInterval        time.Duration
+	dialer              *websocket.Dialer
+
+	ready     chan struct{} // closed once the WebSocket connection is first established
+	readyOnce sync.Once
+	startErr  chan error // receives the error if the first dial fails
+	closed    atomic.Bool
+	draining  atomic.Bool
+	done      chan struct{}
+	errs      errorReporter
 
 	// Multiplexing state
 	ws          *websocket.Conn
 	wsMu        sync.Mutex
 	nextConnID  atomic.Uint32
-	connections sync.Map // map[uint32]net.Conn
+	connections sync.Map // map[uint32]net.Conn, *forwardedConn if idle timeout is set
+	activeConns atomic.Int64
+
+	// Stats
+	totalConns    atomic.Uint64
+	bytesSent     atomic.Uint64
+	bytesReceived atomic.Uint64
+}
+
+// MultiplexedStats is a snapshot of the traffic of a Multiplexed tunnel.
+type MultiplexedStats struct {
+	// ActiveConnections is the number of TCP connections currently forwarded.
+	ActiveConnections int64
+	// TotalConnections is the number of TCP connections accepted since the tunnel started.
+	TotalConnections uint64
+	// BytesSent is the number of bytes read from TCP connections and sent to the remote.
+	BytesSent uint64
+	// BytesReceived is the number of bytes received from the remote and written to TCP connections.
+	BytesReceived uint64
+}
+
+// ActiveConnections returns the number of TCP connections currently forwarded.
+func (t *Multiplexed) ActiveConnections() int {
+	return int(t.activeConns.Load())
+}
+
+// Stats returns the current connection and traffic counters of the tunnel.
+func (t *Multiplexed) Stats() MultiplexedStats {
+	return MultiplexedStats{
+		ActiveConnections: t.activeConns.Load(),
+		TotalConnections:  t.totalConns.Load(),
+		BytesSent:         t.bytesSent.Load(),
+		BytesReceived:     t.bytesReceived.Load(),
+	}
 }
 
 // Start establishes a WebSocket connection and starts listening on TCP connections.
@@ -127,37 +326,97 @@ type Multiplexed struct {
 // It is non-blocking and continues to run in the background.
 // Call Close() method of the returned Multiplexed to make sure it's properly cleaned up.
 func (t *Multiplexed) Start() error {
-	if t.listener == nil {
+	if t.listener == nil && t.packetConn == nil {
 		return fmt.Errorf("tunnel listener is not initialized")
 	}
 	go func() {
-		if err := t.startTunnel(); err != nil {
-			log.Printf("failed to start TCP tunnel: %s", err)
+		if err := t.startTunnel(); err != nil && !t.closed.Load() && !t.draining.Load() {
+			t.errs.report(fmt.Errorf("failed to start TCP tunnel: %w", err))
 		}
 	}()
 	return nil
 }
 
+// Errors returns a channel that receives errors that break the tunnel or its WebSocket
+// connection while it runs, e.g. a failed dial, read or ping, so that programs can react to a
+// dead tunnel. Errors are logged instead if Errors was never called or the receiver falls
+// behind. The channel is never closed.
+func (t *Multiplexed) Errors() <-chan error {
+	return t.errs.channel()
+}
+
+// StartAndWait is like Start but blocks until the WebSocket connection to the remote is
+// established, so that the first forwarded connection doesn't race the dial. It returns an
+// error if the dial fails or ctx is done first.
+func (t *Multiplexed) StartAndWait(ctx context.Context) error {
+	if err := t.Start(); err != nil {
+		return err
+	}
+	select {
+	case <-t.ready:
+		return nil
+	case err := <-t.startErr:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Ready returns a channel that is closed once the WebSocket connection to the remote is
+// established after Start.
+func (t *Multiplexed) Ready() <-chan struct{} {
+	return t.ready
+}
+
+// Addr returns the address the local listener is bound to. If it's bound to all interfaces, the
+// loopback address is returned so that the result can always be dialed locally.
 func (t *Multiplexed) Addr() string {
-	addr, ok := t.listener.Addr().(*net.TCPAddr)
-	if !ok {
-		return t.listener.Addr().String()
+	var local net.Addr
+	if t.packetConn != nil {
+		local = t.packetConn.LocalAddr()
+	} else {
+		local = t.listener.Addr()
 	}
-	return fmt.Sprintf("127.0.0.1:%d", addr.Port)
+	var ip net.IP
+	var port int
+	switch addr := local.(type) {
+	case *net.TCPAddr:
+		ip, port = addr.IP, addr.Port
+	case *net.UDPAddr:
+		ip, port = addr.IP, addr.Port
+	default:
+		return local.String()
+	}
+	if ip.IsUnspecified() {
+		return fmt.Sprintf("127.0.0.1:%d", port)
+	}
+	return local.String()
 }
 
 // Close closes the underlying listener and WebSocket connection.
 func (t *Multiplexed) Close() error {
+	if !t.closed.Swap(true) {
+		close(t.done)
+	}
+
 	var errs []error
 
 	if t.listener != nil {
-		if err := t.listener.Close(); err != nil {
+		if err := t.listener.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			errs = append(errs, fmt.Errorf("closing listener: %w", err))
+		}
+	}
+	if t.packetConn != nil {
+		if err := t.packetConn.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
 			errs = append(errs, fmt.Errorf("closing listener: %w", err))
 		}
 	}
 
-	if t.ws != nil {
-		if err := t.ws.Close(); err != nil {
+	t.wsMu.Lock()
+	ws := t.ws
+	t.wsMu.Unlock()
+	if ws != nil {
+		if err := ws.Close(); err != nil {
 			errs = append(errs, fmt.Errorf("closing websocket: %w", err))
 		}
 	}
@@ -168,55 +427,212 @@ func (t *Multiplexed) Close() error {
 	return nil
 }
 
+// Shutdown gracefully shuts down the tunnel. It stops accepting new TCP connections, waits for
+// the active ones to finish and then closes the WebSocket connection. If ctx is done first, the
+// remaining connections are closed, the remote is told about it, and ctx's error is returned
+// after the tunnel is closed.
+func (t *Multiplexed) Shutdown(ctx context.Context) error {
+	t.draining.Store(true)
+	if t.listener != nil {
+		_ = t.listener.Close()
+	}
+	if t.packetConn != nil {
+		_ = t.packetConn.Close()
+	}
+
+	var ctxErr error
+	ticker := time.NewTicker(shutdownPollInterval)
+	defer ticker.Stop()
+	for t.activeConns.Load() > 0 && ctxErr == nil {
+		select {
+		case <-ctx.Done():
+			ctxErr = ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	if ctxErr != nil {
+		// Removing the connections makes their handlers return and send the close signal.
+		t.connections.Range(func(key, _ any) bool {
+			if conn, ok := t.connections.LoadAndDelete(key); ok {
+				_ = conn.(net.Conn).Close()
+			}
+			return true
+		})
+		deadline := time.Now().Add(forceCloseTimeout)
+		for t.activeConns.Load() > 0 && time.Now().Before(deadline) {
+			time.Sleep(shutdownPollInterval)
+		}
+	}
+
+	t.wsMu.Lock()
+	if t.ws != nil {
+		_ = t.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	}
+	t.wsMu.Unlock()
+	if err := t.Close(); err != nil {
+		return err
+	}
+	return ctxErr
+}
+
 // startTunnel starts the local TCP server and establishes the single persistent
 // WebSocket connection to the remote server. For every TCP connection, a new
 // go routine is started to handle it using the shared WebSocket connection.
 //
 // Blocks until Close() is called.
 func (t *Multiplexed) startTunnel() error {
-	ws, _, err := websocket.DefaultDialer.Dial(t.RemoteURL.String(), http.Header{
+	if err := t.dial(); err != nil {
+		select {
+		case t.startErr <- err:
+		default:
+		}
+		return err
+	}
+	t.readyOnce.Do(func() {
+		close(t.ready)
+	})
+	if t.packetConn != nil {
+		return t.servePackets()
+	}
+
+	for {
+		if t.slots != nil {
+			// Wait for capacity so that new connections queue up in the listener's backlog.
+			select {
+			case t.slots <- struct{}{}:
+			case <-t.done:
+				return net.ErrClosed
+			}
+		}
+		tcpConn, err := t.listener.Accept()
+		if err != nil {
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+		if t.currentWS() == nil {
+			// The WebSocket connection is down and being re-dialed, so there is nothing to
+			// forward to. Reject the connection right away instead of leaving the peer hanging.
+			_ = tcpConn.Close()
+			if t.slots != nil {
+				<-t.slots
+			}
+			continue
+		}
+
+		// Handle each connection in its own goroutine
+		go t.handleConnection(tcpConn)
+	}
+}
+
+// dial establishes the WebSocket connection and starts reading from and pinging it.
+func (t *Multiplexed) dial() error {
+	ws, _, err := t.dialer.Dial(t.RemoteURL.String(), http.Header{
 		"Authorization": []string{fmt.Sprintf("Bearer %s", t.Token)},
 	})
 	if err != nil {
 		return fmt.Errorf("failed to dial remote websocket server: %w", err)
 	}
+	t.wsMu.Lock()
 	t.ws = ws
+	t.wsMu.Unlock()
 
+	stop := make(chan struct{})
 	// Start WebSocket reader to demultiplex incoming messages
-	go t.readFromWebSocket()
+	go t.readFromWebSocket(ws, stop)
+	go t.pingLoop(ws, stop)
+	return nil
+}
 
-	go func() {
-		ticker := time.NewTicker(10 * time.Second)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ticker.C:
-				if err := ws.WriteControl(websocket.PingMessage, []byte{}, time.Now().Add(10*time.Second)); err != nil {
-					log.Printf("websocket ping failed: %v", err)
-				}
+// currentWS returns the WebSocket connection, or nil while it is being re-dialed.
+func (t *Multiplexed) currentWS() *websocket.Conn {
+	t.wsMu.Lock()
+	defer t.wsMu.Unlock()
+	return t.ws
+}
+
+// writeMessage writes msg to ws as long as it is still the tunnel's WebSocket connection. Messages
+// of connections that belong to a dropped WebSocket connection aren't sent to its replacement,
+// since the remote doesn't know their IDs.
+func (t *Multiplexed) writeMessage(ws *websocket.Conn, msg []byte) error {
+	t.wsMu.Lock()
+	defer t.wsMu.Unlock()
+	if ws == nil || ws != t.ws {
+		return errWebSocketDown
+	}
+	return ws.WriteMessage(websocket.BinaryMessage, msg)
+}
+
+// pingLoop keeps the WebSocket connection alive until stop or the tunnel is closed.
+func (t *Multiplexed) pingLoop(ws *websocket.Conn, stop <-chan struct{}) {
+	ticker := time.NewTicker(t.pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.done:
+			return
+		case <-ticker.C:
+			if err := ws.WriteControl(websocket.PingMessage, []byte{}, time.Now().Add(pingWriteTimeout)); err != nil && !t.draining.Load() {
+				t.errs.report(fmt.Errorf("websocket ping failed: %w", err))
 			}
 		}
-	}()
+	}
+}
 
-	for {
-		tcpConn, err := t.listener.Accept()
-		if err != nil {
-			return fmt.Errorf("failed to accept connection: %w", err)
+// reconnect closes the TCP connections of the dropped WebSocket connection and re-dials with
+// exponential backoff. The tunnel is closed if all attempts fail.
+func (t *Multiplexed) reconnect() {
+	// Mark the WebSocket connection as down first so that the connections closed below don't send
+	// their close signals, and new connections are rejected until a new one is dialed.
+	t.wsMu.Lock()
+	ws := t.ws
+	t.ws = nil
+	t.wsMu.Unlock()
+	_ = ws.Close()
+
+	t.connections.Range(func(key, _ any) bool {
+		if conn, ok := t.connections.LoadAndDelete(key); ok {
+			_ = conn.(net.Conn).Close()
 		}
+		return true
+	})
 
-		// Handle each connection in its own goroutine
-		go t.handleConnection(tcpConn)
+	delay := t.reconnectBackoff
+	for attempt := 1; attempt <= t.reconnectRetries; attempt++ {
+		select {
+		case <-t.done:
+			return
+		case <-time.After(delay):
+		}
+		err := t.dial()
+		if err == nil {
+			log.Printf("websocket reconnected after %d attempt(s)", attempt)
+			return
+		}
+		log.Printf("websocket reconnect attempt %d failed: %v", attempt, err)
+		delay *= 2
 	}
+	t.errs.report(fmt.Errorf("websocket reconnect attempts exhausted after %d attempts, closing tunnel", t.reconnectRetries))
+	_ = t.Close()
 }
 
 // readFromWebSocket reads from the WebSocket and forwards messages to the correct TCP connection.
 // Message format: [4 bytes: connection ID][data]
 // Empty data indicates connection close signal.
-func (t *Multiplexed) readFromWebSocket() {
+func (t *Multiplexed) readFromWebSocket(ws *websocket.Conn, stop chan struct{}) {
 	for {
-		_, message, err := t.ws.ReadMessage()
+		_, message, err := ws.ReadMessage()
 		if err != nil {
-			log.Printf("websocket read error: %v", err)
+			close(stop)
+			if t.closed.Load() || t.draining.Load() {
+				// The connection was closed on purpose, e.g. the server echoed the close frame
+				// sent by Shutdown.
+				return
+			}
+			t.errs.report(fmt.Errorf("websocket read error: %w", err))
+			if t.reconnectRetries > 0 {
+				t.reconnect()
+			}
 			return
 		}
 
@@ -228,6 +644,10 @@ func (t *Multiplexed) readFromWebSocket() {
 
 		conn, ok := t.connections.Load(connID)
 		if !ok {
+			if t.onUnknownConnection != nil {
+				t.onUnknownConnection(connID, len(data) > 0)
+				continue
+			}
 			// When connection is closed, both sides send empty data. The server
 			// may send it after we closed and cleaned up the connection so we ignore
 			// the message if we're closed and it's empty.
@@ -251,7 +671,23 @@ func (t *Multiplexed) readFromWebSocket() {
 			t.connections.Delete(connID)
 			continue
 		}
-		if _, err := tcpConn.Write(data); err != nil {
+		if fc, ok := tcpConn.(*forwardedConn); ok {
+			fc.touch()
+			// Data is handed to the connection's writer so that a slow peer doesn't hold up the
+			// other connections.
+			if !fc.queue(data) {
+				log.Printf("tcp connection %d doesn't keep up with the remote, closing it", connID)
+				_ = tcpConn.Close()
+				t.connections.Delete(connID)
+			}
+			continue
+		}
+		if t.readTimeout > 0 {
+			_ = tcpConn.SetWriteDeadline(time.Now().Add(t.readTimeout))
+		}
+		n, err := tcpConn.Write(data)
+		t.bytesReceived.Add(uint64(n))
+		if err != nil {
 			log.Printf("failed to write to tcp connection %d: %v", connID, err)
 			_ = tcpConn.Close()
 			t.connections.Delete(connID)
@@ -259,42 +695,151 @@ func (t *Multiplexed) readFromWebSocket() {
 	}
 }
 
+// forwardedConn is a forwarded TCP connection. It records when bytes last flowed through it and
+// queues data from the remote for writeToConnection.
+type forwardedConn struct {
+	net.Conn
+	lastActive atomic.Int64 // unix nanoseconds
+	writes     chan []byte
+	stopped    chan struct{}
+	stopOnce   sync.Once
+}
+
+func newForwardedConn(conn net.Conn) *forwardedConn {
+	c := &forwardedConn{
+		Conn:    conn,
+		writes:  make(chan []byte, connWriteQueueSize),
+		stopped: make(chan struct{}),
+	}
+	c.touch()
+	return c
+}
+
+// queue queues data to be written to the connection. It returns false if the queue is full.
+func (c *forwardedConn) queue(data []byte) bool {
+	select {
+	case c.writes <- data:
+		return true
+	case <-c.stopped:
+		return true
+	default:
+		return false
+	}
+}
+
+// stop stops writeToConnection.
+func (c *forwardedConn) stop() {
+	c.stopOnce.Do(func() {
+		close(c.stopped)
+	})
+}
+
+func (c *forwardedConn) touch() {
+	c.lastActive.Store(time.Now().UnixNano())
+}
+
+// idleFor returns how long no bytes flowed through the connection.
+func (c *forwardedConn) idleFor() time.Duration {
+	return time.Since(time.Unix(0, c.lastActive.Load()))
+}
+
+// writeToConnection writes the data queued by readFromWebSocket to the connection until it's
+// stopped. The connection is closed if a write fails.
+func (t *Multiplexed) writeToConnection(connID uint32, fc *forwardedConn) {
+	for {
+		select {
+		case <-fc.stopped:
+			return
+		case data := <-fc.writes:
+			if t.readTimeout > 0 {
+				_ = fc.SetWriteDeadline(time.Now().Add(t.readTimeout))
+			}
+			n, err := fc.Write(data)
+			t.bytesReceived.Add(uint64(n))
+			if err != nil {
+				log.Printf("failed to write to tcp connection %d: %v", connID, err)
+				_ = fc.Close()
+				t.connections.Delete(connID)
+				return
+			}
+		}
+	}
+}
+
 // handleConnection handles a single TCP connection by multiplexing it over the shared WebSocket.
 // Message format: [4 bytes: connection ID][data]
 func (t *Multiplexed) handleConnection(tcpConn net.Conn) {
+	ws := t.currentWS()
 	connID := t.nextConnID.Add(1)
-	t.connections.Store(connID, tcpConn)
+	fc := newForwardedConn(tcpConn)
+	defer fc.stop()
+	go t.writeToConnection(connID, fc)
+	t.connections.Store(connID, fc)
+	t.activeConns.Add(1)
+	t.totalConns.Add(1)
+	defer func() {
+		t.activeConns.Add(-1)
+		if t.slots != nil {
+			<-t.slots
+		}
+	}()
 
 	defer func() {
 		_ = tcpConn.Close()
 		t.connections.Delete(connID)
 
 		// Send close signal: [4 bytes: connID][empty data]
-		closeMsg := encodeMessage(connID, nil)
-		t.wsMu.Lock()
-		defer t.wsMu.Unlock()
-		_ = t.ws.WriteMessage(websocket.BinaryMessage, closeMsg)
+		_ = t.writeMessage(ws, encodeMessage(connID, nil))
 	}()
-	buffer := make([]byte, 32*1024) // 32KB data buffer
+	buffer := make([]byte, t.bufferSize)
+	lastRead := time.Now()
 	for {
+		var deadline time.Time
+		if t.idleTimeout > 0 {
+			// Data from the remote resets the idle time too, so the deadline is extended from the
+			// last activity in either direction.
+			deadline = time.Now().Add(t.idleTimeout - fc.idleFor())
+		}
+		if t.readTimeout > 0 {
+			if d := lastRead.Add(t.readTimeout); deadline.IsZero() || d.Before(deadline) {
+				deadline = d
+			}
+		}
+		if !deadline.IsZero() {
+			_ = tcpConn.SetReadDeadline(deadline)
+		}
 		n, err := tcpConn.Read(buffer)
+		if n > 0 {
+			lastRead = time.Now()
+			fc.touch()
+		}
 		if err != nil {
-			if err == io.EOF {
-				// io.EOF is expected when the connection is closed by the client.
-				return
+			if ne, ok := err.(net.Error); ok && ne.Timeout() && !deadline.IsZero() {
+				idle := t.idleTimeout > 0 && fc.idleFor() >= t.idleTimeout
+				stuck := t.readTimeout > 0 && time.Since(lastRead) >= t.readTimeout
+				if idle || stuck {
+					// The deferred cleanup sends the close signal to the remote.
+					return
+				}
+				continue
 			}
-			log.Printf("tcp->ws: error reading from connection %d: %v", connID, err)
-			continue
+			// io.EOF is expected when the connection is closed by the client, and the connection
+			// is already removed if it was closed on our side, e.g. because the WebSocket
+			// connection dropped. Anything else, like a reset, is logged; the connection is
+			// unusable either way so the deferred cleanup closes it.
+			if _, ok := t.connections.Load(connID); ok && !errors.Is(err, io.EOF) {
+				log.Printf("tcp->ws: error reading from connection %d: %v", connID, err)
+			}
+			return
 		}
 		if n == 0 {
 			continue
 		}
-		t.wsMu.Lock()
-		err = t.ws.WriteMessage(websocket.BinaryMessage, encodeMessage(connID, buffer[:n]))
-		t.wsMu.Unlock()
-		if err != nil {
+		if err := t.writeMessage(ws, encodeMessage(connID, buffer[:n])); err != nil {
+			// The data is lost, so close the connection to let the peer know.
 			log.Printf("failed to write to websocket: %v", err)
-			continue
+			return
 		}
+		t.bytesSent.Add(uint64(n))
 	}
 }
@@ -0,0 +1,249 @@
+package tunnel
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// newSinkServer starts a server that reads and discards all messages and counts its connections.
+// It drops the connection when it receives "bye".
+func newSinkServer(t *testing.T, dials *atomic.Int32) *url.URL {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		dials.Add(1)
+		defer ws.Close()
+		for {
+			_, message, err := ws.ReadMessage()
+			if err != nil {
+				return
+			}
+			if _, data, err := decodeMessage(message); err == nil && string(data) == "bye" {
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	u, err := url.Parse("ws" + strings.TrimPrefix(srv.URL, "http"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+// newEchoServer starts a server that sends every message back and the IDs of closed connections
+// to closed, if not nil.
+func newEchoServer(t *testing.T, closed chan<- uint32) *url.URL {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		for {
+			_, message, err := ws.ReadMessage()
+			if err != nil {
+				return
+			}
+			connID, data, err := decodeMessage(message)
+			if err != nil {
+				return
+			}
+			if len(data) == 0 {
+				if closed != nil {
+					closed <- connID
+				}
+				continue
+			}
+			if err := ws.WriteMessage(websocket.BinaryMessage, message); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	u, err := url.Parse("ws" + strings.TrimPrefix(srv.URL, "http"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+// TestShutdownDoesNotReconnect makes sure that the WebSocket connection going away during
+// Shutdown is neither reported as an error nor answered with a reconnect.
+func TestShutdownDoesNotReconnect(t *testing.T) {
+	var dials atomic.Int32
+	u := newSinkServer(t, &dials)
+	m, err := NewMultiplexed(u, 1, "token", MultiplexedWithReconnect(3, 10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := m.Errors()
+	if err := m.StartAndWait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	conn, err := net.Dial("tcp", m.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		_ = m.Shutdown(ctx)
+	}()
+	// Let Shutdown start draining the connection, then make the server drop the WebSocket
+	// connection while it waits.
+	time.Sleep(50 * time.Millisecond)
+	if _, err := conn.Write([]byte("bye")); err != nil {
+		t.Fatal(err)
+	}
+	<-shutdownDone
+
+	select {
+	case err := <-errs:
+		t.Fatalf("unexpected error after Shutdown: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+	if n := dials.Load(); n != 1 {
+		t.Fatalf("expected a single dial, got %d", n)
+	}
+}
+
+// TestUDPSourcesExpire makes sure that UDP sources count as active connections until they're
+// idle for the idle timeout, and that the remote is told to close them then.
+func TestUDPSourcesExpire(t *testing.T) {
+	closed := make(chan uint32, 1)
+	u := newEchoServer(t, closed)
+	m, err := NewMultiplexed(u, 1, "token",
+		MultiplexedWithNetwork("udp"),
+		MultiplexedWithBindAddress("127.0.0.1"),
+		MultiplexedWithIdleTimeout(100*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if err := m.StartAndWait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	conn, err := net.Dial("udp", m.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 16)
+	n, err := conn.Read(buf)
+	if err != nil || string(buf[:n]) != "ping" {
+		t.Fatalf("expected the datagram to be echoed, got %q, %v", buf[:n], err)
+	}
+	if active := m.ActiveConnections(); active != 1 {
+		t.Fatalf("expected 1 active connection, got %d", active)
+	}
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("idle source wasn't closed")
+	}
+	if active := m.ActiveConnections(); active != 0 {
+		t.Fatalf("expected no active connections after the source expired, got %d", active)
+	}
+}
+
+// TestSlowPeerDoesNotBlockOthers makes sure that a peer that doesn't read what the remote sends
+// doesn't hold up data for the other connections.
+func TestSlowPeerDoesNotBlockOthers(t *testing.T) {
+	m, err := NewMultiplexed(newEchoServer(t, nil), 1, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if err := m.StartAndWait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	slow, err := net.Dial("tcp", m.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer slow.Close()
+	// The slow peer sends a lot but never reads the echo, so its socket buffers fill up.
+	go func() {
+		data := make([]byte, 32*1024)
+		for i := 0; i < 512; i++ {
+			if _, err := slow.Write(data); err != nil {
+				return
+			}
+		}
+	}()
+	time.Sleep(200 * time.Millisecond)
+
+	fast, err := net.Dial("tcp", m.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fast.Close()
+	if _, err := fast.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	_ = fast.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(fast, buf); err != nil {
+		t.Fatalf("fast connection was held up by the slow one: %v", err)
+	}
+}
+
+// TestRejectsConnectionsWhileReconnecting makes sure that connections accepted while the
+// WebSocket connection is being re-dialed are closed instead of left hanging.
+func TestRejectsConnectionsWhileReconnecting(t *testing.T) {
+	var dials atomic.Int32
+	m, err := NewMultiplexed(newSinkServer(t, &dials), 1, "token", MultiplexedWithReconnect(1, time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.StartAndWait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	conn, err := net.Dial("tcp", m.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("bye")); err != nil {
+		t.Fatal(err)
+	}
+	// Give the tunnel time to notice that the server dropped the WebSocket connection.
+	time.Sleep(100 * time.Millisecond)
+
+	conn2, err := net.Dial("tcp", m.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn2.Close()
+	_ = conn2.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
+	if _, err := conn2.Read(make([]byte, 1)); err != io.EOF {
+		t.Fatalf("expected the connection to be closed during the reconnect, got %v", err)
+	}
+}
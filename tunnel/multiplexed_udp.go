@@ -0,0 +1,159 @@
+package tunnel
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MultiplexedWithNetwork sets the network the local listener uses: "tcp" (the default), "tcp4",
+// "tcp6", "udp", "udp4" or "udp6".
+//
+// In UDP mode, every source address that sends a datagram gets its own connection ID and every
+// datagram is sent as a single WebSocket message with the usual header; messages from the remote
+// are sent back as datagrams to that address. Datagrams may be dropped or reordered just like
+// with plain UDP, and the server must support datagram framing for the remote port.
+func MultiplexedWithNetwork(network string) MultiplexedOption {
+	return func(r *Multiplexed) {
+		r.network = network
+	}
+}
+
+// isUDP reports whether the tunnel forwards datagrams rather than TCP connections.
+func (t *Multiplexed) isUDP() bool {
+	switch t.network {
+	case "udp", "udp4", "udp6":
+		return true
+	}
+	return false
+}
+
+// defaultUDPIdleTimeout is how long a UDP source is remembered without traffic if no idle timeout
+// is set with MultiplexedWithIdleTimeout.
+const defaultUDPIdleTimeout = 2 * time.Minute
+
+// udpConn is the net.Conn stored in the connections map for a UDP source address. Writing sends
+// a datagram to the source address and closing only forgets the address.
+type udpConn struct {
+	pc         net.PacketConn
+	addr       net.Addr
+	connID     uint32
+	lastActive atomic.Int64 // unix nanoseconds
+	onClose    func()
+	once       sync.Once
+}
+
+func (c *udpConn) touch() {
+	c.lastActive.Store(time.Now().UnixNano())
+}
+
+// idleFor returns how long no datagrams were sent from or to the source.
+func (c *udpConn) idleFor() time.Duration {
+	return time.Since(time.Unix(0, c.lastActive.Load()))
+}
+
+func (c *udpConn) Read([]byte) (int, error) {
+	return 0, errors.New("read from a udp source directly is not supported")
+}
+
+func (c *udpConn) Write(b []byte) (int, error) {
+	c.touch()
+	return c.pc.WriteTo(b, c.addr)
+}
+
+func (c *udpConn) Close() error {
+	c.once.Do(c.onClose)
+	return nil
+}
+
+func (c *udpConn) LocalAddr() net.Addr              { return c.pc.LocalAddr() }
+func (c *udpConn) RemoteAddr() net.Addr             { return c.addr }
+func (c *udpConn) SetDeadline(time.Time) error      { return nil }
+func (c *udpConn) SetReadDeadline(time.Time) error  { return nil }
+func (c *udpConn) SetWriteDeadline(time.Time) error { return nil }
+
+// servePackets reads datagrams from the local packet listener and forwards them over the shared
+// WebSocket, allocating a connection ID for every new source address. Sources count as active
+// connections until they're idle for the idle timeout, defaultUDPIdleTimeout if not set, or the
+// remote closes them. Datagrams from new sources are dropped while MultiplexedWithMaxConnections
+// is reached.
+//
+// Blocks until Close() is called.
+func (t *Multiplexed) servePackets() error {
+	var sources sync.Map // map[string]*udpConn, keyed by source address
+	idleTimeout := t.idleTimeout
+	if idleTimeout <= 0 {
+		idleTimeout = defaultUDPIdleTimeout
+	}
+	go t.expireSources(&sources, idleTimeout)
+
+	buffer := make([]byte, t.bufferSize)
+	for {
+		n, addr, err := t.packetConn.ReadFrom(buffer)
+		if err != nil {
+			return fmt.Errorf("failed to read datagram: %w", err)
+		}
+		key := addr.String()
+		var src *udpConn
+		if v, ok := sources.Load(key); ok {
+			src = v.(*udpConn)
+		} else {
+			if t.slots != nil {
+				select {
+				case t.slots <- struct{}{}:
+				default:
+					log.Printf("dropping datagram from %s: maximum number of connections reached", addr)
+					continue
+				}
+			}
+			src = &udpConn{pc: t.packetConn, addr: addr, connID: t.nextConnID.Add(1)}
+			src.onClose = func() {
+				sources.Delete(key)
+				t.connections.Delete(src.connID)
+				t.activeConns.Add(-1)
+				if t.slots != nil {
+					<-t.slots
+				}
+			}
+			src.touch()
+			t.connections.Store(src.connID, src)
+			sources.Store(key, src)
+			t.activeConns.Add(1)
+			t.totalConns.Add(1)
+		}
+		src.touch()
+		if err := t.writeMessage(t.currentWS(), encodeMessage(src.connID, buffer[:n])); err != nil {
+			t.errs.report(fmt.Errorf("failed to forward datagram from %s to websocket: %w", addr, err))
+			continue
+		}
+		t.bytesSent.Add(uint64(n))
+	}
+}
+
+// expireSources forgets UDP sources that were idle for idleTimeout and tells the remote to close
+// their connections, until the tunnel is closed.
+func (t *Multiplexed) expireSources(sources *sync.Map, idleTimeout time.Duration) {
+	ticker := time.NewTicker(idleTimeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-ticker.C:
+		}
+		sources.Range(func(_, v any) bool {
+			src := v.(*udpConn)
+			if src.idleFor() < idleTimeout {
+				return true
+			}
+			_ = src.Close()
+			// Send close signal: [4 bytes: connID][empty data]
+			_ = t.writeMessage(t.currentWS(), encodeMessage(src.connID, nil))
+			return true
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package tunnel
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// WebDriverAgentPort is the port WebDriverAgent listens on in iOS instances.
+const WebDriverAgentPort = 8100
+
+// NewWebDriverAgent returns a Multiplexed tunnel to WebDriverAgent of an iOS instance. Pass the
+// EndpointWebSocketURL and Token fields of the instance's status:
+//
+//	t, err := tunnel.NewWebDriverAgent(instance.Status.EndpointWebSocketURL, instance.Status.Token)
+//
+// Call Start on the returned tunnel and point your WebDriver client to its Addr.
+func NewWebDriverAgent(endpointWebSocketURL, token string, opts ...MultiplexedOption) (*Multiplexed, error) {
+	u, err := url.Parse(endpointWebSocketURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint websocket url: %w", err)
+	}
+	return NewMultiplexed(u, WebDriverAgentPort, token, opts...)
+}
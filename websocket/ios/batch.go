@@ -0,0 +1,87 @@
+package ios
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// BatchCommand is a command to run as part of Batch. Create one with TapCmd, TapElementCmd,
+// TypeTextCmd or PressKeyCmd.
+type BatchCommand struct {
+	req request
+}
+
+// TapCmd returns a command that taps at the given coordinates, like Tap.
+func TapCmd(x, y float64) BatchCommand {
+	return BatchCommand{req: request{Type: "tap", X: x, Y: y}}
+}
+
+// TapElementCmd returns a command that taps the element matching the selector, like TapElement.
+func TapElementCmd(selector AccessibilitySelector) BatchCommand {
+	return BatchCommand{req: request{Type: "tapElement", Selector: &selector}}
+}
+
+// TypeTextCmd returns a command that types the given text, like TypeText.
+func TypeTextCmd(text string, pressEnter bool) BatchCommand {
+	return BatchCommand{req: request{Type: "typeText", Text: text, PressEnter: pressEnter}}
+}
+
+// PressKeyCmd returns a command that presses a key with optional modifiers, like PressKey.
+func PressKeyCmd(key string, modifiers ...string) BatchCommand {
+	return BatchCommand{req: request{Type: "pressKey", Key: key, Modifiers: modifiers}}
+}
+
+// BatchResult is the result of a command run by Batch.
+type BatchResult struct {
+	// Type is the type of the command, e.g. "tap".
+	Type string `json:"type"`
+	// ElementLabel and ElementType describe the element a TapElementCmd tapped.
+	ElementLabel string `json:"elementLabel,omitempty"`
+	ElementType  string `json:"elementType,omitempty"`
+}
+
+// batchResult is a BatchResult as sent by the server.
+type batchResult struct {
+	BatchResult
+	Error string `json:"error,omitempty"`
+}
+
+// Batch runs the given commands in order in a single round trip, which saves the latency of
+// sending them one by one. The server stops at the first command that fails; Batch then returns
+// the results of the commands that succeeded before it together with the error.
+func (c *Client) Batch(ctx context.Context, cmds ...BatchCommand) ([]BatchResult, error) {
+	if len(cmds) == 0 {
+		return nil, errors.New("batch needs at least one command")
+	}
+	commands := make([]request, len(cmds))
+	for i, cmd := range cmds {
+		commands[i] = cmd.req
+	}
+	req := &request{Type: "batch", Commands: commands}
+	resp, err := c.sendRequest(ctx, req)
+	if resp == nil {
+		return nil, err
+	}
+	// The server reports the failing command both in its result and as the error of the batch,
+	// so the results are read either way.
+	results := make([]BatchResult, 0, len(resp.Results))
+	for i, r := range resp.Results {
+		if r.Error != "" {
+			return results, &CommandError{
+				RequestID: req.ID,
+				Type:      r.Type,
+				Message:   r.Error,
+				Err:       fmt.Errorf("batch command %d: %w", i, serverError(r.Error)),
+			}
+		}
+		results = append(results, r.BatchResult)
+	}
+	if err != nil {
+		return results, err
+	}
+	if len(results) < len(cmds) {
+		return results, fmt.Errorf("batch returned %d results for %d commands", len(results), len(cmds))
+	}
+	return results, nil
+}
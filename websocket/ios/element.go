@@ -0,0 +1,363 @@
+package ios
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// elementPollInterval is how often the element tree is fetched while waiting for an element.
+	elementPollInterval = 250 * time.Millisecond
+	// defaultScrollAttempts is how often ScrollToElement scrolls by default.
+	defaultScrollAttempts = 10
+)
+
+// ScrollDirection is the direction content is scrolled in.
+type ScrollDirection string
+
+const (
+	ScrollUp    ScrollDirection = "Up"
+	ScrollDown  ScrollDirection = "Down"
+	ScrollLeft  ScrollDirection = "Left"
+	ScrollRight ScrollDirection = "Right"
+)
+
+// ScrollOptions configures ScrollToElementWithOptions.
+type ScrollOptions struct {
+	// MaxAttempts is the maximum number of scrolls. Defaults to 10.
+	MaxAttempts int
+}
+
+// AccessibilityFrame is the frame of an element in the element tree, in points.
+type AccessibilityFrame struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// AccessibilityElement is a node of the accessibility hierarchy of the screen.
+type AccessibilityElement struct {
+	// Type is the element type, e.g. "Button" or "StaticText".
+	Type       string `json:"type"`
+	Label      string `json:"AXLabel"`
+	Value      string `json:"AXValue"`
+	Identifier string `json:"AXUniqueId"`
+	Title      string `json:"title"`
+	Enabled    bool   `json:"enabled"`
+	// Frame is the position and size of the element on screen.
+	Frame    AccessibilityFrame     `json:"frame"`
+	Children []AccessibilityElement `json:"children"`
+}
+
+// parseElementTree parses the JSON returned by ElementTree. The server returns
+// either a list of root elements or a single root element.
+func parseElementTree(tree string) ([]AccessibilityElement, error) {
+	data := bytes.TrimSpace([]byte(tree))
+	if len(data) == 0 {
+		return nil, nil
+	}
+	if data[0] == '[' {
+		var roots []AccessibilityElement
+		if err := json.Unmarshal(data, &roots); err != nil {
+			return nil, fmt.Errorf("parse element tree: %w", err)
+		}
+		return roots, nil
+	}
+	var root AccessibilityElement
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("parse element tree: %w", err)
+	}
+	return []AccessibilityElement{root}, nil
+}
+
+// xmlHierarchy is the document root of the XML element tree.
+type xmlHierarchy struct {
+	XMLName  xml.Name `xml:"hierarchy"`
+	Elements []AccessibilityElement
+}
+
+// MarshalXML renders the element as a node named after its type, as used by ElementTreeXML,
+// so that XPath expressions like //Button[@label="Submit"] work as expected.
+func (e AccessibilityElement) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
+	start := xml.StartElement{Name: xml.Name{Local: xmlElementName(e.Type)}}
+	attr := func(name, value string) {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: name}, Value: value})
+	}
+	attr("type", e.Type)
+	if e.Label != "" {
+		attr("label", e.Label)
+	}
+	if e.Identifier != "" {
+		attr("identifier", e.Identifier)
+	}
+	if e.Value != "" {
+		attr("value", e.Value)
+	}
+	if e.Title != "" {
+		attr("title", e.Title)
+	}
+	attr("enabled", strconv.FormatBool(e.Enabled))
+	attr("x", formatPoint(e.Frame.X))
+	attr("y", formatPoint(e.Frame.Y))
+	attr("width", formatPoint(e.Frame.Width))
+	attr("height", formatPoint(e.Frame.Height))
+
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, child := range e.Children {
+		if err := enc.Encode(child); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// xmlElementName returns the element type if it is a valid XML name, and
+// "Other" otherwise.
+func xmlElementName(elementType string) string {
+	if elementType == "" {
+		return "Other"
+	}
+	for i, r := range elementType {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case i > 0 && (r == '-' || r == '.' || r >= '0' && r <= '9'):
+		default:
+			return "Other"
+		}
+	}
+	return elementType
+}
+
+func formatPoint(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// ElementTreeXML returns the accessibility hierarchy of the current screen as XML.
+// Every element becomes a node named after its type with type, label, identifier,
+// value, enabled and frame (x, y, width, height) attributes, which makes the
+// output usable with XPath-based selectors and inspector tools.
+func (c *Client) ElementTreeXML(ctx context.Context, point *AccessibilityPoint) (string, error) {
+	tree, err := c.ElementTree(ctx, point)
+	if err != nil {
+		return "", err
+	}
+	roots, err := parseElementTree(tree)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(xmlHierarchy{Elements: roots}); err != nil {
+		return "", fmt.Errorf("encode element tree: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// ElementTreeParsed returns the accessibility hierarchy of the current screen parsed into
+// AccessibilityElement. If the server reports more than one root element, they're returned as
+// children of a root element without a type.
+func (c *Client) ElementTreeParsed(ctx context.Context, point *AccessibilityPoint) (*AccessibilityElement, error) {
+	tree, err := c.ElementTree(ctx, point)
+	if err != nil {
+		return nil, err
+	}
+	roots, err := parseElementTree(tree)
+	if err != nil {
+		return nil, err
+	}
+	if len(roots) == 1 {
+		return &roots[0], nil
+	}
+	return &AccessibilityElement{Children: roots}, nil
+}
+
+// FindElement returns the first element matching the selector without interacting with it.
+// It returns an error if no element matches.
+func (c *Client) FindElement(ctx context.Context, selector AccessibilitySelector) (*AccessibilityElement, error) {
+	resp, err := c.sendRequest(ctx, &request{Type: "findElement", Selector: &selector})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Element == nil {
+		return nil, fmt.Errorf("%w: no element matches selector %+v", ErrElementNotFound, selector)
+	}
+	return resp.Element, nil
+}
+
+// FindElements returns all elements matching the selector without interacting with them.
+// It returns an empty slice if no element matches.
+func (c *Client) FindElements(ctx context.Context, selector AccessibilitySelector) ([]AccessibilityElement, error) {
+	resp, err := c.sendRequest(ctx, &request{Type: "findElements", Selector: &selector})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Elements, nil
+}
+
+// ScrollToElement scrolls in the given direction until an element matching the selector is
+// visible and returns it. It gives up after 10 scrolls.
+func (c *Client) ScrollToElement(ctx context.Context, selector AccessibilitySelector, direction ScrollDirection) (*AccessibilityElement, error) {
+	return c.ScrollToElementWithOptions(ctx, selector, direction, nil)
+}
+
+// ScrollToElementWithOptions is like ScrollToElement but allows setting the maximum number of
+// scrolls in opts.
+func (c *Client) ScrollToElementWithOptions(ctx context.Context, selector AccessibilitySelector, direction ScrollDirection, opts *ScrollOptions) (*AccessibilityElement, error) {
+	maxAttempts := defaultScrollAttempts
+	if opts != nil && opts.MaxAttempts > 0 {
+		maxAttempts = opts.MaxAttempts
+	}
+	resp, err := c.sendRequest(ctx, &request{
+		Type:        "scrollToElement",
+		Selector:    &selector,
+		Direction:   direction,
+		MaxAttempts: maxAttempts,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Element == nil {
+		return nil, fmt.Errorf("%w: no element matches selector %+v after %d scrolls", ErrElementNotFound, selector, maxAttempts)
+	}
+	return resp.Element, nil
+}
+
+// matches reports whether the element satisfies all non-empty fields of the selector.
+func (s AccessibilitySelector) matches(e *AccessibilityElement) bool {
+	return (s.AccessibilityID == "" || e.Identifier == s.AccessibilityID) &&
+		(s.Label == "" || e.Label == s.Label) &&
+		(s.LabelContains == "" || strings.Contains(e.Label, s.LabelContains)) &&
+		(s.ElementType == "" || e.Type == s.ElementType) &&
+		(s.Title == "" || e.Title == s.Title) &&
+		(s.TitleContains == "" || strings.Contains(e.Title, s.TitleContains)) &&
+		(s.Value == "" || e.Value == s.Value)
+}
+
+// findElement returns the first element that matches the selector.
+func findElement(elements []AccessibilityElement, selector AccessibilitySelector) *AccessibilityElement {
+	if matched := findElements(elements, selector); len(matched) > 0 {
+		return matched[0]
+	}
+	return nil
+}
+
+// findElements returns all elements that match the selector in depth-first order. If the
+// selector has a container, only descendants of the matching containers are considered.
+func findElements(elements []AccessibilityElement, selector AccessibilitySelector) []*AccessibilityElement {
+	var matched []*AccessibilityElement
+	if selector.Container != nil {
+		inner := selector
+		inner.Container = nil
+		seen := make(map[*AccessibilityElement]bool)
+		for _, container := range findElements(elements, *selector.Container) {
+			for _, e := range findElements(container.Children, inner) {
+				if !seen[e] {
+					seen[e] = true
+					matched = append(matched, e)
+				}
+			}
+		}
+		return matched
+	}
+	var walk func([]AccessibilityElement)
+	walk = func(elements []AccessibilityElement) {
+		for i := range elements {
+			if selector.matches(&elements[i]) {
+				matched = append(matched, &elements[i])
+			}
+			walk(elements[i].Children)
+		}
+	}
+	walk(elements)
+	return matched
+}
+
+// waitForElement polls the element tree until an element matching the selector appears.
+func (c *Client) waitForElement(ctx context.Context, selector AccessibilitySelector, timeout time.Duration) (*AccessibilityElement, error) {
+	return c.pollElementTree(ctx, timeout, fmt.Sprintf("element %+v", selector), func(roots []AccessibilityElement) *AccessibilityElement {
+		return findElement(roots, selector)
+	})
+}
+
+// pollElementTree fetches the element tree until find returns an element or the timeout passes.
+// what describes the awaited element in the timeout error.
+func (c *Client) pollElementTree(ctx context.Context, timeout time.Duration, what string, find func([]AccessibilityElement) *AccessibilityElement) (*AccessibilityElement, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for {
+		tree, err := c.ElementTree(ctx, nil)
+		if err != nil && ctx.Err() == nil {
+			return nil, err
+		}
+		if err == nil {
+			roots, err := parseElementTree(tree)
+			if err != nil {
+				return nil, err
+			}
+			if e := find(roots); e != nil {
+				return e, nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%s did not appear within %s: %w", what, timeout, ctx.Err())
+		case <-time.After(elementPollInterval):
+		}
+	}
+}
+
+// OpenURLAndWait opens a URL, typically a deep link, and waits until an element matching expect
+// appears on screen to confirm that the link was routed to the right screen.
+// It returns the matched element, or an error if it doesn't appear within the timeout.
+func (c *Client) OpenURLAndWait(ctx context.Context, urlStr string, expect AccessibilitySelector, timeout time.Duration) (*TapElementResult, error) {
+	if err := c.OpenURL(ctx, urlStr); err != nil {
+		return nil, err
+	}
+	e, err := c.waitForElement(ctx, expect, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &TapElementResult{
+		ElementLabel: e.Label,
+		ElementType:  e.Type,
+	}, nil
+}
+
+// containsText reports whether the label, value or title of the element contains text.
+func (e *AccessibilityElement) containsText(text string) bool {
+	return strings.Contains(e.Label, text) || strings.Contains(e.Value, text) || strings.Contains(e.Title, text)
+}
+
+// findText returns the first element in depth-first order whose label, value or title contains text.
+func findText(elements []AccessibilityElement, text string) *AccessibilityElement {
+	for i := range elements {
+		if elements[i].containsText(text) {
+			return &elements[i]
+		}
+		if e := findText(elements[i].Children, text); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// WaitForText polls the element tree until any element's label, value or title contains the
+// given text, regardless of its type or position in the hierarchy. It returns an error if the
+// text doesn't appear within the timeout.
+func (c *Client) WaitForText(ctx context.Context, text string, timeout time.Duration) error {
+	_, err := c.pollElementTree(ctx, timeout, fmt.Sprintf("text %q", text), func(roots []AccessibilityElement) *AccessibilityElement {
+		return findText(roots, text)
+	})
+	return err
+}
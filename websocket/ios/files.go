@@ -0,0 +1,122 @@
+package ios
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+const (
+	// uploadChunkSize is the maximum number of bytes sent in a single upload message.
+	uploadChunkSize = 512 * 1024
+	// maxContainerFileSize is the maximum size of a file transferred with PushFile or PullFile.
+	maxContainerFileSize = 16 * 1024 * 1024
+)
+
+// MediaFile is a photo or video to add to the photo library with AddMedia.
+type MediaFile struct {
+	// Name is the file name. Its extension determines the media type, e.g. "photo.jpg" or "clip.mp4".
+	Name string
+	// Data is the content of the file.
+	Data []byte
+}
+
+// uploadFile uploads data to a temporary file on the instance in chunks and returns its path.
+func (c *Client) uploadFile(ctx context.Context, name string, data []byte) (string, error) {
+	remotePath := ""
+	for offset := 0; offset == 0 || offset < len(data); offset += uploadChunkSize {
+		end := min(offset+uploadChunkSize, len(data))
+		resp, err := c.sendRequest(ctx, &request{
+			Type: "uploadFile",
+			Name: name,
+			Path: remotePath,
+			Data: base64.StdEncoding.EncodeToString(data[offset:end]),
+		})
+		if err != nil {
+			return "", fmt.Errorf("upload %s: %w", name, err)
+		}
+		remotePath = resp.Path
+		if end == len(data) {
+			break
+		}
+	}
+	if remotePath == "" {
+		return "", fmt.Errorf("upload %s: server did not return a path", name)
+	}
+	return remotePath, nil
+}
+
+// AddMedia uploads the given photos and videos to the instance and adds them to the photo
+// library of the simulator with "simctl addmedia".
+func (c *Client) AddMedia(ctx context.Context, files ...MediaFile) error {
+	if len(files) == 0 {
+		return errors.New("no media files given")
+	}
+	args := []string{"addmedia", "booted"}
+	for _, f := range files {
+		name := path.Base(f.Name)
+		if name == "" || name == "." || name == "/" {
+			return fmt.Errorf("invalid media file name %q", f.Name)
+		}
+		remotePath, err := c.uploadFile(ctx, name, f.Data)
+		if err != nil {
+			return err
+		}
+		args = append(args, remotePath)
+	}
+	out, err := c.Simctl(ctx, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("simctl addmedia: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// validContainerPath validates a path relative to the data container of an app and returns it cleaned.
+func validContainerPath(p string) (string, error) {
+	cleaned := path.Clean(p)
+	if p == "" || path.IsAbs(cleaned) || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("invalid container path %q: must be a file path relative to the app container", p)
+	}
+	return cleaned, nil
+}
+
+// PushFile writes data to a file in the data container of the installed app, e.g.
+// "Documents/import.json". The path is relative to the container and must not leave it.
+// Files are sent in a single message and are limited to 16 MiB.
+func (c *Client) PushFile(ctx context.Context, bundleID, containerPath string, data []byte) error {
+	p, err := validContainerPath(containerPath)
+	if err != nil {
+		return err
+	}
+	if len(data) > maxContainerFileSize {
+		return fmt.Errorf("file is %d bytes, larger than the limit of %d bytes", len(data), maxContainerFileSize)
+	}
+	_, err = c.sendRequest(ctx, &request{
+		Type:     "pushFile",
+		BundleID: bundleID,
+		Path:     p,
+		Data:     base64.StdEncoding.EncodeToString(data),
+	})
+	return err
+}
+
+// PullFile reads a file from the data container of the installed app. The path is relative to
+// the container and must not leave it. Files larger than 16 MiB are rejected by the server.
+func (c *Client) PullFile(ctx context.Context, bundleID, containerPath string) ([]byte, error) {
+	p, err := validContainerPath(containerPath)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.sendRequest(ctx, &request{Type: "pullFile", BundleID: bundleID, Path: p})
+	if err != nil {
+		return nil, err
+	}
+	data, err := base64.StdEncoding.DecodeString(resp.Base64)
+	if err != nil {
+		return nil, fmt.Errorf("decode file: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,44 @@
+package ios
+
+import (
+	"context"
+	"fmt"
+)
+
+// SetLocationOptions holds optional details of a simulated location.
+type SetLocationOptions struct {
+	// Altitude is the altitude in meters above sea level.
+	Altitude *float64
+	// HorizontalAccuracy is the radius of uncertainty of the location in meters.
+	HorizontalAccuracy *float64
+}
+
+// SetLocation simulates the device being at the given coordinates until ClearLocation is called.
+// The latitude must be between -90 and 90 and the longitude between -180 and 180.
+func (c *Client) SetLocation(ctx context.Context, latitude, longitude float64) error {
+	return c.SetLocationWithOptions(ctx, latitude, longitude, nil)
+}
+
+// SetLocationWithOptions is like SetLocation but also sets the altitude and accuracy of the
+// location if given in opts.
+func (c *Client) SetLocationWithOptions(ctx context.Context, latitude, longitude float64, opts *SetLocationOptions) error {
+	if latitude < -90 || latitude > 90 {
+		return fmt.Errorf("latitude must be between -90 and 90, got %v", latitude)
+	}
+	if longitude < -180 || longitude > 180 {
+		return fmt.Errorf("longitude must be between -180 and 180, got %v", longitude)
+	}
+	req := &request{Type: "setLocation", Lat: &latitude, Lon: &longitude}
+	if opts != nil {
+		req.Altitude = opts.Altitude
+		req.Accuracy = opts.HorizontalAccuracy
+	}
+	_, err := c.sendRequest(ctx, req)
+	return err
+}
+
+// ClearLocation stops simulating a location set with SetLocation.
+func (c *Client) ClearLocation(ctx context.Context) error {
+	_, err := c.sendRequest(ctx, &request{Type: "clearLocation"})
+	return err
+}
@@ -0,0 +1,52 @@
+// Package otelios connects the iOS WebSocket client to OpenTelemetry.
+//
+// It's a separate module so that the SDK itself doesn't depend on OpenTelemetry.
+package otelios
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/limrun-inc/go-sdk/websocket/ios"
+)
+
+// Attribute keys set on the span of every request.
+const (
+	RequestTypeKey = attribute.Key("limrun.request.type")
+	RequestIDKey   = attribute.Key("limrun.request.id")
+)
+
+// WithTracer makes the client start a client span with the given tracer for every request. The
+// span is named after the request type and its trace ID is sent to the server with the request,
+// see ios.ContextWithTraceID. Failed requests record their error on the span.
+func WithTracer(tracer trace.Tracer) ios.Option {
+	return ios.WithTracer(requestTracer{tracer: tracer})
+}
+
+// requestTracer implements ios.RequestTracer with an OpenTelemetry tracer.
+type requestTracer struct {
+	tracer trace.Tracer
+}
+
+func (t requestTracer) StartRequest(ctx context.Context, requestType, requestID string) (context.Context, func(error)) {
+	ctx, span := t.tracer.Start(ctx, requestType,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			RequestTypeKey.String(requestType),
+			RequestIDKey.String(requestID),
+		),
+	)
+	if sc := span.SpanContext(); sc.HasTraceID() {
+		ctx = ios.ContextWithTraceID(ctx, sc.TraceID().String())
+	}
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
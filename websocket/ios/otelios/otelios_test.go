@@ -0,0 +1,104 @@
+package otelios_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/limrun-inc/go-sdk/websocket/ios"
+	"github.com/limrun-inc/go-sdk/websocket/ios/otelios"
+)
+
+// newServer starts a server that acknowledges every request, failing taps at x=0, and sends the
+// trace IDs it receives to traceIDs.
+func newServer(t *testing.T, traceIDs chan<- string) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		for {
+			_, message, err := ws.ReadMessage()
+			if err != nil {
+				return
+			}
+			var req struct {
+				Type    string  `json:"type"`
+				ID      string  `json:"id"`
+				TraceID string  `json:"traceId"`
+				X       float64 `json:"x"`
+			}
+			if err := json.Unmarshal(message, &req); err != nil {
+				return
+			}
+			traceIDs <- req.TraceID
+			resp := map[string]string{"type": req.Type + "Result", "id": req.ID}
+			if req.X == 0 {
+				resp["error"] = "tap failed"
+			}
+			data, _ := json.Marshal(resp)
+			if err := ws.WriteMessage(websocket.TextMessage, data); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestWithTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	traceIDs := make(chan string, 2)
+	srv := newServer(t, traceIDs)
+
+	c, err := ios.NewClient(srv.URL, "token", otelios.WithTracer(provider.Tracer("test")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if err := c.Tap(context.Background(), 1, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Tap(context.Background(), 0, 1); err == nil {
+		t.Fatal("expected the second tap to fail")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	for i, span := range spans {
+		if span.Name() != "tap" {
+			t.Errorf("span %d: expected name tap, got %q", i, span.Name())
+		}
+		if got := <-traceIDs; got != span.SpanContext().TraceID().String() {
+			t.Errorf("span %d: server got trace ID %q, span has %q", i, got, span.SpanContext().TraceID())
+		}
+		var requestID string
+		for _, attr := range span.Attributes() {
+			if attr.Key == otelios.RequestIDKey {
+				requestID = attr.Value.AsString()
+			}
+		}
+		if requestID == "" {
+			t.Errorf("span %d: missing %s attribute", i, otelios.RequestIDKey)
+		}
+	}
+	if code := spans[0].Status().Code; code != codes.Unset {
+		t.Errorf("expected the successful request to leave the status unset, got %v", code)
+	}
+	if code := spans[1].Status().Code; code != codes.Error {
+		t.Errorf("expected the failed request to set an error status, got %v", code)
+	}
+}
@@ -0,0 +1,31 @@
+package ios
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+)
+
+// StartRecording starts recording the screen of the simulator. Call StopRecording to stop it and
+// get the video.
+func (c *Client) StartRecording(ctx context.Context) error {
+	_, err := c.sendRequest(ctx, &request{Type: "startRecording"})
+	return err
+}
+
+// StopRecording stops the recording started with StartRecording and returns the video as MP4.
+//
+// The whole video is sent in a single message and held in memory, so this is best suited for
+// short recordings. For long recordings, use IO().RecordVideo to record to a file on the instance
+// instead.
+func (c *Client) StopRecording(ctx context.Context) ([]byte, error) {
+	resp, err := c.sendRequest(ctx, &request{Type: "stopRecording"})
+	if err != nil {
+		return nil, err
+	}
+	video, err := base64.StdEncoding.DecodeString(resp.Base64)
+	if err != nil {
+		return nil, fmt.Errorf("decode recording: %w", err)
+	}
+	return video, nil
+}
@@ -0,0 +1,121 @@
+package ios
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// screenshotAspectTolerance is the relative difference allowed between the aspect ratio of the
+// decoded image and the one of the reported dimensions, to account for rounding of pixel sizes.
+const screenshotAspectTolerance = 0.02
+
+// decodeScreenshotBytes returns the raw JPEG bytes of the screenshot.
+func decodeScreenshotBytes(data *ScreenshotData) ([]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(data.Base64)
+	if err != nil {
+		return nil, fmt.Errorf("decode screenshot base64: %w", err)
+	}
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("screenshot is empty")
+	}
+	return raw, nil
+}
+
+// decodeScreenshotImage decodes the JPEG image of the screenshot.
+func decodeScreenshotImage(data *ScreenshotData) (image.Image, error) {
+	raw, err := decodeScreenshotBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	img, err := jpeg.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("decode screenshot jpeg: %w", err)
+	}
+	return img, nil
+}
+
+// validateScreenshot decodes the image in the screenshot and checks that it's a non-empty JPEG
+// with the same aspect ratio as the reported width and height.
+func validateScreenshot(data *ScreenshotData) error {
+	// Decoding the full image rather than only its header catches truncated payloads.
+	img, err := decodeScreenshotImage(data)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidScreenshot, err)
+	}
+	bounds := img.Bounds()
+	if bounds.Dx() == 0 || bounds.Dy() == 0 {
+		return fmt.Errorf("%w: image has no pixels", ErrInvalidScreenshot)
+	}
+	if data.Width <= 0 || data.Height <= 0 {
+		return fmt.Errorf("%w: reported dimensions %vx%v are not positive", ErrInvalidScreenshot, data.Width, data.Height)
+	}
+	expected := data.Width / data.Height
+	actual := float64(bounds.Dx()) / float64(bounds.Dy())
+	if math.Abs(actual-expected)/expected > screenshotAspectTolerance {
+		return fmt.Errorf("%w: image is %dx%d pixels but reported dimensions are %vx%v points",
+			ErrInvalidScreenshot, bounds.Dx(), bounds.Dy(), data.Width, data.Height)
+	}
+	return nil
+}
+
+// ScreenshotImage takes a screenshot and returns the decoded image. Note that the image is in
+// pixels while ScreenshotData reports its dimensions in points.
+func (c *Client) ScreenshotImage(ctx context.Context) (image.Image, error) {
+	data, err := c.Screenshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return decodeScreenshotImage(data)
+}
+
+// ScreenshotToFile takes a screenshot and saves it to the given local path. The format is chosen by
+// the file extension: ".jpg" and ".jpeg" write the JPEG as received, ".png" re-encodes it as PNG.
+// The returned ScreenshotData contains the dimensions of the screenshot.
+func (c *Client) ScreenshotToFile(ctx context.Context, path string) (*ScreenshotData, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".jpg" && ext != ".jpeg" && ext != ".png" {
+		return nil, fmt.Errorf("unsupported screenshot file extension %q, use .jpg, .jpeg or .png", ext)
+	}
+	dir := filepath.Dir(path)
+	if info, err := os.Stat(dir); err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("directory %s does not exist", dir)
+		}
+		return nil, err
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	data, err := c.Screenshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := decodeScreenshotBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	if ext == ".png" {
+		img, err := jpeg.Decode(bytes.NewReader(raw))
+		if err != nil {
+			return nil, fmt.Errorf("decode screenshot jpeg: %w", err)
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, fmt.Errorf("encode screenshot png: %w", err)
+		}
+		raw = buf.Bytes()
+	}
+	if err := os.WriteFile(path, raw, 0o644); err != nil {
+		return nil, fmt.Errorf("write screenshot: %w", err)
+	}
+	return data, nil
+}
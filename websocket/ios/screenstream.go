@@ -0,0 +1,123 @@
+package ios
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// screenFrameBuffer is the number of frames buffered for a slow receiver before the oldest ones
+// are dropped.
+const screenFrameBuffer = 8
+
+// StreamOptions configures StreamScreen. Zero values use the server defaults.
+type StreamOptions struct {
+	// FPS is the maximum number of frames per second.
+	FPS int
+	// Quality is the JPEG quality of the frames, between 1 and 100.
+	Quality int
+}
+
+// ScreenFrame is a single frame of a screen stream.
+type ScreenFrame struct {
+	// JPEG is the JPEG-encoded image of the frame.
+	JPEG []byte
+	// Timestamp is when the frame was captured.
+	Timestamp time.Time
+}
+
+// StreamScreen starts streaming the screen of the simulator and returns a channel of frames.
+// The stream stops and the channel is closed when ctx is done or the connection is closed.
+//
+// The oldest buffered frames are dropped if the receiver doesn't keep up, so the channel always
+// delivers the most recent frames, which is what live views need.
+func (c *Client) StreamScreen(ctx context.Context, opts StreamOptions) (<-chan ScreenFrame, error) {
+	s := newScreenStream()
+	req := &request{
+		Type:    "startScreenStream",
+		ID:      c.nextRequestID(),
+		FPS:     opts.FPS,
+		Quality: opts.Quality,
+	}
+	c.screenStreams.Store(req.ID, s)
+	if _, err := c.sendRequest(ctx, req); err != nil {
+		c.screenStreams.Delete(req.ID)
+		return nil, err
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-c.done:
+		case <-s.done:
+		}
+		if _, ok := c.screenStreams.LoadAndDelete(req.ID); !ok {
+			return // Already closed with the connection
+		}
+		s.close()
+		data, err := json.Marshal(&request{Type: "stopScreenStream", ID: req.ID})
+		if err != nil {
+			return
+		}
+		if err := c.writeMessage(data); err != nil {
+			c.logger.Debug("failed to stop screen stream", "id", req.ID, "error", err)
+		}
+	}()
+	return s.ch, nil
+}
+
+// screenStream delivers the frames of a screen stream to its channel.
+type screenStream struct {
+	mu     sync.Mutex
+	ch     chan ScreenFrame
+	done   chan struct{}
+	closed bool
+}
+
+func newScreenStream() *screenStream {
+	return &screenStream{
+		ch:   make(chan ScreenFrame, screenFrameBuffer),
+		done: make(chan struct{}),
+	}
+}
+
+func (s *screenStream) handleFrame(resp *response) {
+	data, err := base64.StdEncoding.DecodeString(resp.Base64)
+	if err != nil {
+		return
+	}
+	frame := ScreenFrame{JPEG: data, Timestamp: time.Now()}
+	if resp.Timestamp > 0 {
+		frame.Timestamp = time.UnixMilli(resp.Timestamp)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	for {
+		select {
+		case s.ch <- frame:
+			return
+		default:
+		}
+		// The buffer is full; make room by dropping the oldest frame, unless the receiver just
+		// took it.
+		select {
+		case <-s.ch:
+		default:
+		}
+	}
+}
+
+func (s *screenStream) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+		close(s.done)
+	}
+}
@@ -7,12 +7,41 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"slices"
 	"sync"
+	"syscall"
 	"time"
-
-	"github.com/gorilla/websocket"
 )
 
+// stderrTailSize is the number of trailing bytes of standard error kept for ExitError when
+// Stderr is nil.
+const stderrTailSize = 4096
+
+// maxQueuedOutput is the number of bytes of output queued for a slow Stdout or Stderr before the
+// command is terminated with ErrOutputOverflow.
+const maxQueuedOutput = 16 << 20
+
+// ErrOutputOverflow is returned by Wait when Stdout or Stderr didn't keep up with the output of
+// the command, e.g. because a StdoutPipe wasn't drained, and the command was terminated.
+var ErrOutputOverflow = errors.New("simctl: output buffer overflow")
+
+// ExitError is returned by Wait when the command exits with a non-zero exit code.
+type ExitError struct {
+	*ProcessState
+
+	// Stderr holds the last bytes of the standard error output of the command if Stderr
+	// wasn't set.
+	Stderr []byte
+}
+
+func (e *ExitError) Error() string {
+	msg := fmt.Sprintf("simctl: exit code %d", e.ExitCode())
+	if stderr := bytes.TrimSpace(e.Stderr); len(stderr) > 0 {
+		msg += ": " + string(stderr)
+	}
+	return msg
+}
+
 // SimctlCmd represents a simctl command to be run remotely.
 // Its API mirrors os/exec.Cmd for familiarity.
 type SimctlCmd struct {
@@ -29,18 +58,35 @@ type SimctlCmd struct {
 	// If Stderr is the same as Stdout, both are written to the same writer.
 	Stderr io.Writer
 
+	// ReplayLines, if positive, keeps the last ReplayLines lines of standard output so that a
+	// command continued with Resume skips the lines that were already delivered. Standard
+	// output is then delivered line by line.
+	ReplayLines int
+
 	client         *Client
 	ctx            context.Context
 	id             string
 	started        bool
+	exited         bool
 	finished       bool
+	killed         bool
+	startTime      time.Time
+	endTime        time.Time
 	mu             sync.Mutex
+	outMu          sync.Mutex // guards outQueue, outQueued and outClosed
+	outCond        *sync.Cond
+	outQueue       []outputChunk
+	outQueued      int // bytes in outQueue
+	outClosed      bool
+	outDone        chan struct{} // closed when writeOutput returns
 	done           chan struct{}
 	err            error
 	exitCode       int
 	stdoutPipe     *io.PipeWriter
 	stderrPipe     *io.PipeWriter
 	closeAfterWait []io.Closer
+	replay         *replayWriter
+	stderrTail     *tailBuffer
 }
 
 // Run starts the command and waits for it to complete.
@@ -67,23 +113,32 @@ func (c *SimctlCmd) Start() error {
 		return ErrNotConnected
 	}
 
-	c.id = fmt.Sprintf("go-%d-%d", time.Now().UnixNano(), c.client.requestID.Add(1))
+	if c.Stderr == nil {
+		c.stderrTail = &tailBuffer{max: stderrTailSize}
+	}
+	if c.replay == nil && c.ReplayLines > 0 && c.Stdout != nil {
+		c.replay = &replayWriter{w: c.Stdout, max: c.ReplayLines}
+	}
+
+	c.id = c.client.nextRequestID()
+	c.startTime = time.Now()
 	c.done = make(chan struct{})
+	c.outCond = sync.NewCond(&c.outMu)
+	c.outDone = make(chan struct{})
+	go c.writeOutput()
 	c.client.simctlExecutions.Store(c.id, c)
 
 	req := &request{Type: "simctl", ID: c.id, Args: c.Args}
+	req.TraceID, _ = TraceIDFromContext(c.ctx)
 	data, err := json.Marshal(req)
 	if err != nil {
 		c.client.simctlExecutions.Delete(c.id)
 		return fmt.Errorf("marshal request: %w", err)
 	}
 
-	c.client.logger.Debug("sending simctl request", "id", c.id, "args", c.Args)
+	c.client.logger.Debug("sending simctl request", "id", c.id, "args", c.Args, "traceId", req.TraceID)
 
-	c.client.wsMu.Lock()
-	err = c.client.ws.WriteMessage(websocket.TextMessage, data)
-	c.client.wsMu.Unlock()
-	if err != nil {
+	if err := c.client.writeMessage(data); err != nil {
 		c.client.simctlExecutions.Delete(c.id)
 		return fmt.Errorf("send request: %w", err)
 	}
@@ -124,7 +179,11 @@ func (c *SimctlCmd) Wait() error {
 		return c.err
 	}
 	if c.exitCode != 0 {
-		return fmt.Errorf("simctl: exit code %d", c.exitCode)
+		exitErr := &ExitError{ProcessState: c.State()}
+		if c.stderrTail != nil {
+			exitErr.Stderr = c.stderrTail.buf
+		}
+		return exitErr
 	}
 	return nil
 }
@@ -135,8 +194,65 @@ func (c *SimctlCmd) ExitCode() int {
 	return c.exitCode
 }
 
+// State returns information about the exited command, or nil if Wait hasn't returned yet.
+func (c *SimctlCmd) State() *ProcessState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.finished {
+		return nil
+	}
+	exitCode := c.exitCode
+	if !c.exited {
+		exitCode = -1
+	}
+	return &ProcessState{
+		exitCode: exitCode,
+		killed:   c.killed,
+		elapsed:  c.endTime.Sub(c.startTime),
+	}
+}
+
+// ProcessState describes a command that has finished, like os.ProcessState.
+type ProcessState struct {
+	exitCode int
+	killed   bool
+	elapsed  time.Duration
+}
+
+// ExitCode returns the exit code of the command, or -1 if it didn't report one, e.g. because the
+// connection was closed.
+func (p *ProcessState) ExitCode() int {
+	return p.exitCode
+}
+
+// Success reports whether the command exited with exit code 0.
+func (p *ProcessState) Success() bool {
+	return p.exitCode == 0
+}
+
+// Killed reports whether the command was terminated with Kill, either directly or because its
+// context was done.
+func (p *ProcessState) Killed() bool {
+	return p.killed
+}
+
+// Elapsed returns the time from Start until the command exited.
+func (p *ProcessState) Elapsed() time.Duration {
+	return p.elapsed
+}
+
+func (p *ProcessState) String() string {
+	if p.killed {
+		return fmt.Sprintf("killed (exit code %d)", p.exitCode)
+	}
+	return fmt.Sprintf("exit code %d", p.exitCode)
+}
+
 // StdoutPipe returns a pipe that will be connected to the command's standard output when the command starts.
-// Wait will close the pipe after seeing the command exit.
+// The pipe is closed once the command has exited and all of its output was read, so reading
+// until EOF before calling Wait is safe. Output is buffered, so a slow reader doesn't hold up
+// other requests of the client; a reader that falls more than 16 MiB behind gets the command
+// terminated, and Wait returns ErrOutputOverflow.
 func (c *SimctlCmd) StdoutPipe() (io.ReadCloser, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -156,7 +272,10 @@ func (c *SimctlCmd) StdoutPipe() (io.ReadCloser, error) {
 }
 
 // StderrPipe returns a pipe that will be connected to the command's standard error when the command starts.
-// Wait will close the pipe after seeing the command exit.
+// The pipe is closed once the command has exited and all of its output was read, so reading
+// until EOF before calling Wait is safe. Output is buffered, so a slow reader doesn't hold up
+// other requests of the client; a reader that falls more than 16 MiB behind gets the command
+// terminated, and Wait returns ErrOutputOverflow.
 func (c *SimctlCmd) StderrPipe() (io.ReadCloser, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -201,51 +320,221 @@ func (c *SimctlCmd) CombinedOutput() ([]byte, error) {
 	return buf.Bytes(), err
 }
 
+// outputChunk is output waiting to be written by writeOutput.
+type outputChunk struct {
+	stdout, stderr []byte
+}
+
 // handleOutput is called by the client's readLoop to deliver output data.
-func (c *SimctlCmd) handleOutput(stdout, stderr []byte, exitCode *int) {
-	if len(stdout) > 0 && c.Stdout != nil {
-		c.Stdout.Write(stdout)
+//
+// Output is queued and written to Stdout and Stderr by writeOutput, so that a slow reader,
+// e.g. of an undrained StdoutPipe, doesn't block readLoop and with it every other request.
+// The queue holds up to maxQueuedOutput bytes; output beyond that is dropped and the command is
+// terminated and fails with ErrOutputOverflow, since its output would be incomplete.
+//
+// The stream ends as described on the simctlStream fields of response: with the exit code
+// message, or with the done message if the exit code message set done to false. Messages of a
+// connection arrive in order, so all output sent before the end of the stream is delivered.
+func (c *SimctlCmd) handleOutput(stdout, stderr []byte, exitCode *int, done *bool) {
+	overflow := false
+	if n := len(stdout) + len(stderr); n > 0 {
+		c.outMu.Lock()
+		switch {
+		case c.outClosed:
+		case c.outQueued+n > maxQueuedOutput:
+			overflow = true
+		default:
+			c.outQueue = append(c.outQueue, outputChunk{stdout: stdout, stderr: stderr})
+			c.outQueued += n
+			c.outCond.Signal()
+		}
+		c.outMu.Unlock()
 	}
-	if len(stderr) > 0 && c.Stderr != nil {
-		c.Stderr.Write(stderr)
+
+	c.mu.Lock()
+	if c.finished {
+		c.mu.Unlock()
+		return
 	}
-	if exitCode != nil {
-		c.mu.Lock()
-		c.exitCode = *exitCode
-		c.finished = true
+	if overflow {
+		c.err = ErrOutputOverflow
+		running := !c.exited
+		c.killed = running
+		c.endTime = time.Now()
+		id := c.id
 		c.mu.Unlock()
-		close(c.done)
+		// Unblock writeOutput, which is most likely stuck writing to an undrained pipe.
+		if c.stdoutPipe != nil {
+			c.stdoutPipe.CloseWithError(ErrOutputOverflow)
+		}
+		if c.stderrPipe != nil {
+			c.stderrPipe.CloseWithError(ErrOutputOverflow)
+		}
+		if running {
+			// writeMessage may block, which readLoop must not.
+			go func() {
+				if err := c.client.sendTerminate(id); err != nil {
+					c.client.logger.Debug("failed to terminate simctl command", "id", id, "error", err)
+				}
+			}()
+		}
+		c.finish()
+		return
+	}
+	if exitCode != nil && !c.exited {
+		c.exited = true
+		c.exitCode = *exitCode
+		c.endTime = time.Now()
+	}
+	end := (done != nil && *done) || (done == nil && exitCode != nil)
+	if end && c.endTime.IsZero() {
+		c.endTime = time.Now()
+	}
+	c.mu.Unlock()
+
+	if end {
+		c.finish()
 	}
 }
 
 // handleError is called when the connection is closed unexpectedly.
+// It's ignored if the exit code was already received.
 func (c *SimctlCmd) handleError(err error) {
 	c.mu.Lock()
 	if c.finished {
 		c.mu.Unlock()
 		return
 	}
-	c.err = err
+	if !c.exited {
+		c.err = err
+		c.endTime = time.Now()
+	}
+	c.mu.Unlock()
+	c.finish()
+}
+
+// writeOutput writes queued output to Stdout and Stderr until the command is finished and the
+// queue is drained.
+func (c *SimctlCmd) writeOutput() {
+	defer close(c.outDone)
+	c.outMu.Lock()
+	for {
+		for len(c.outQueue) == 0 && !c.outClosed {
+			c.outCond.Wait()
+		}
+		if len(c.outQueue) == 0 {
+			c.outMu.Unlock()
+			return
+		}
+		chunk := c.outQueue[0]
+		c.outQueue = c.outQueue[1:]
+		c.outQueued -= len(chunk.stdout) + len(chunk.stderr)
+		c.outMu.Unlock()
+
+		if len(chunk.stdout) > 0 && c.replay != nil {
+			c.replay.Write(chunk.stdout)
+		} else if len(chunk.stdout) > 0 && c.Stdout != nil {
+			c.Stdout.Write(chunk.stdout)
+		}
+		if len(chunk.stderr) > 0 && c.Stderr != nil {
+			c.Stderr.Write(chunk.stderr)
+		} else if len(chunk.stderr) > 0 && c.stderrTail != nil {
+			c.stderrTail.Write(chunk.stderr)
+		}
+
+		c.outMu.Lock()
+	}
+}
+
+// finish unregisters the command and releases Wait once all queued output is written.
+// It doesn't block, so it's safe to call from readLoop.
+func (c *SimctlCmd) finish() {
+	c.mu.Lock()
+	if c.finished {
+		c.mu.Unlock()
+		return
+	}
 	c.finished = true
+	err := c.err
 	c.mu.Unlock()
-	close(c.done)
+	c.client.simctlExecutions.Delete(c.id)
+
+	c.outMu.Lock()
+	c.outClosed = true
+	c.outCond.Signal()
+	c.outMu.Unlock()
+
+	go func() {
+		<-c.outDone
+		if c.replay != nil && err == nil {
+			c.replay.flush()
+		}
+		// Close the pipes once all output is written so that readers see EOF before calling Wait.
+		if c.stdoutPipe != nil {
+			c.stdoutPipe.CloseWithError(err)
+		}
+		if c.stderrPipe != nil {
+			c.stderrPipe.CloseWithError(err)
+		}
+		close(c.done)
+	}()
+}
+
+// Resume starts the command again after Wait returned ErrConnectionClose, e.g. once the client
+// has reconnected, and returns the new command. Output continues on the same Stdout and Stderr;
+// with ReplayLines set, lines that were already delivered before the connection dropped are
+// skipped.
+//
+// Resume is not supported for commands using StdoutPipe or StderrPipe since those are closed
+// by Wait.
+func (c *SimctlCmd) Resume(ctx context.Context) (*SimctlCmd, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.finished || !errors.Is(c.err, ErrConnectionClose) {
+		return nil, errors.New("simctl: Resume is only possible after the connection was closed")
+	}
+	if c.stdoutPipe != nil || c.stderrPipe != nil {
+		return nil, errors.New("simctl: Resume with pipes is not supported")
+	}
+	next := &SimctlCmd{
+		Args:        c.Args,
+		Stdout:      c.Stdout,
+		Stderr:      c.Stderr,
+		ReplayLines: c.ReplayLines,
+		client:      c.client,
+		ctx:         ctx,
+	}
+	if c.replay != nil {
+		c.replay.resume()
+		next.replay = c.replay
+	}
+	if err := next.Start(); err != nil {
+		return nil, err
+	}
+	return next, nil
 }
 
-// Kill terminates the running command by sending a terminate request to the server.
-// The process will exit and Wait will return with an error indicating termination.
+// Kill terminates the running command by sending a terminate request to the server, which
+// kills the process like SIGKILL. The process will exit and Wait will return with an error
+// indicating termination. Use Signal for a graceful shutdown.
 func (c *SimctlCmd) Kill() error {
 	c.mu.Lock()
 	if !c.started {
 		c.mu.Unlock()
 		return errors.New("simctl: not started")
 	}
-	if c.finished {
+	if c.exited || c.finished {
 		c.mu.Unlock()
 		return nil // Already finished
 	}
 	id := c.id
+	c.killed = true
 	c.mu.Unlock()
+	return c.client.sendTerminate(id)
+}
 
+// sendTerminate asks the server to terminate the simctl command with the given ID.
+func (c *Client) sendTerminate(id string) error {
 	req := struct {
 		Type string `json:"type"`
 		ID   string `json:"id"`
@@ -259,12 +548,109 @@ func (c *SimctlCmd) Kill() error {
 		return fmt.Errorf("marshal terminate request: %w", err)
 	}
 
-	c.client.wsMu.Lock()
-	err = c.client.ws.WriteMessage(websocket.TextMessage, data)
-	c.client.wsMu.Unlock()
-	if err != nil {
+	if err := c.writeMessage(data); err != nil {
 		return fmt.Errorf("send terminate request: %w", err)
 	}
 
 	return nil
 }
+
+// Signal sends a signal to the running command, e.g. syscall.SIGINT to stop "log stream" and let
+// it flush its output before Wait returns. Unlike Kill, the command may handle the signal.
+func (c *SimctlCmd) Signal(sig syscall.Signal) error {
+	c.mu.Lock()
+	if !c.started {
+		c.mu.Unlock()
+		return errors.New("simctl: not started")
+	}
+	if c.exited || c.finished {
+		c.mu.Unlock()
+		return nil // Already finished
+	}
+	id := c.id
+	c.mu.Unlock()
+
+	req := struct {
+		Type   string `json:"type"`
+		ID     string `json:"id"`
+		Signal int    `json:"signal"`
+	}{
+		Type:   "simctlSignal",
+		ID:     id,
+		Signal: int(sig),
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("marshal signal request: %w", err)
+	}
+
+	if err := c.client.writeMessage(data); err != nil {
+		return fmt.Errorf("send signal request: %w", err)
+	}
+
+	return nil
+}
+
+// replayWriter delivers output line by line while remembering the most recent lines, so that
+// output repeated by a resumed command can be skipped.
+type replayWriter struct {
+	w       io.Writer
+	max     int
+	lines   []string
+	partial []byte
+	dedup   bool
+}
+
+func (r *replayWriter) Write(p []byte) (int, error) {
+	r.partial = append(r.partial, p...)
+	for {
+		i := bytes.IndexByte(r.partial, '\n')
+		if i < 0 {
+			break
+		}
+		line := string(r.partial[:i])
+		r.partial = r.partial[i+1:]
+		if r.dedup && slices.Contains(r.lines, line) {
+			continue
+		}
+		r.dedup = false
+		if _, err := io.WriteString(r.w, line+"\n"); err != nil {
+			return len(p), err
+		}
+		r.lines = append(r.lines, line)
+		if len(r.lines) > r.max {
+			r.lines = r.lines[len(r.lines)-r.max:]
+		}
+	}
+	return len(p), nil
+}
+
+// flush writes the incomplete last line, if any.
+func (r *replayWriter) flush() {
+	if len(r.partial) > 0 {
+		_, _ = r.w.Write(r.partial)
+		r.partial = nil
+	}
+}
+
+// resume drops the incomplete last line, which the resumed command sends again in full, and
+// starts skipping lines that were already delivered.
+func (r *replayWriter) resume() {
+	r.partial = nil
+	r.dedup = len(r.lines) > 0
+}
+
+// tailBuffer keeps the last max bytes written to it.
+type tailBuffer struct {
+	buf []byte
+	max int
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.max {
+		b.buf = append([]byte(nil), b.buf[len(b.buf)-b.max:]...)
+	}
+	return len(p), nil
+}
@@ -0,0 +1,92 @@
+package ios
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// SimctlIO runs "simctl io" subcommands on the booted simulator. Paths are on the instance.
+// Create one with Client.IO.
+type SimctlIO struct {
+	client *Client
+	ctx    context.Context
+}
+
+// RecordVideoOptions configures SimctlIO.RecordVideo. Zero values use the simctl defaults.
+type RecordVideoOptions struct {
+	// Codec is the video codec, "h264" or "hevc".
+	Codec string
+	// Display is the display to record, "internal" or "external".
+	Display string
+	// Mask is the policy for non-rectangular displays, "ignored", "alpha" or "black".
+	Mask string
+	// Force overwrites the output file if it already exists.
+	Force bool
+}
+
+// IO returns a SimctlIO to run "simctl io" subcommands with the given context.
+func (c *Client) IO(ctx context.Context) *SimctlIO {
+	return &SimctlIO{client: c, ctx: ctx}
+}
+
+// Screenshot saves a screenshot of the simulator to the given path. The image type is
+// chosen by the file extension and defaults to PNG.
+func (s *SimctlIO) Screenshot(path string) error {
+	args := []string{"io", "booted", "screenshot"}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jpg", ".jpeg":
+		args = append(args, "--type=jpeg")
+	case ".tif", ".tiff":
+		args = append(args, "--type=tiff")
+	case ".bmp":
+		args = append(args, "--type=bmp")
+	case ".gif":
+		args = append(args, "--type=gif")
+	}
+	return s.run(append(args, path)...)
+}
+
+// RecordVideo starts recording the screen of the simulator to the given path and returns the
+// running command. Recording continues until the command is stopped, e.g. with Kill, or its
+// context is done.
+func (s *SimctlIO) RecordVideo(path string, opts *RecordVideoOptions) (*SimctlCmd, error) {
+	args := []string{"io", "booted", "recordVideo"}
+	if opts != nil {
+		if opts.Codec != "" {
+			args = append(args, "--codec="+opts.Codec)
+		}
+		if opts.Display != "" {
+			args = append(args, "--display="+opts.Display)
+		}
+		if opts.Mask != "" {
+			args = append(args, "--mask="+opts.Mask)
+		}
+		if opts.Force {
+			args = append(args, "--force")
+		}
+	}
+	cmd := s.client.Simctl(s.ctx, append(args, path)...)
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+// Enumerate returns the list of IO ports of the simulator as printed by simctl.
+func (s *SimctlIO) Enumerate() (string, error) {
+	out, err := s.client.Simctl(s.ctx, "io", "booted", "enumerate").CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("simctl io enumerate: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
+
+func (s *SimctlIO) run(args ...string) error {
+	out, err := s.client.Simctl(s.ctx, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("simctl %s: %w: %s", strings.Join(args[:3], " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package ios
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// SimctlListResult is the parsed output of "simctl list -j".
+type SimctlListResult struct {
+	DeviceTypes []SimctlDeviceType `json:"devicetypes"`
+	Runtimes    []SimctlRuntime    `json:"runtimes"`
+	// Devices maps runtime identifiers to the devices created for that runtime.
+	Devices map[string][]SimctlDevice `json:"devices"`
+	// Pairs maps pair UDIDs to watch and phone pairs.
+	Pairs map[string]SimctlPair `json:"pairs"`
+}
+
+// SimctlDeviceType is a kind of device that simulators can be created for, e.g. "iPhone 16".
+type SimctlDeviceType struct {
+	Identifier              string `json:"identifier"`
+	Name                    string `json:"name"`
+	ProductFamily           string `json:"productFamily"`
+	ModelIdentifier         string `json:"modelIdentifier"`
+	BundlePath              string `json:"bundlePath"`
+	MinRuntimeVersion       int64  `json:"minRuntimeVersion"`
+	MinRuntimeVersionString string `json:"minRuntimeVersionString"`
+	MaxRuntimeVersion       int64  `json:"maxRuntimeVersion"`
+	MaxRuntimeVersionString string `json:"maxRuntimeVersionString"`
+}
+
+// SimctlRuntime is an installed simulator runtime, e.g. "iOS 18.2".
+type SimctlRuntime struct {
+	Identifier           string             `json:"identifier"`
+	Name                 string             `json:"name"`
+	Version              string             `json:"version"`
+	BuildVersion         string             `json:"buildversion"`
+	Platform             string             `json:"platform"`
+	BundlePath           string             `json:"bundlePath"`
+	RuntimeRoot          string             `json:"runtimeRoot"`
+	IsAvailable          bool               `json:"isAvailable"`
+	IsInternal           bool               `json:"isInternal"`
+	SupportedDeviceTypes []SimctlDeviceType `json:"supportedDeviceTypes"`
+}
+
+// SimctlDevice is a simulator device.
+type SimctlDevice struct {
+	UDID                 string `json:"udid"`
+	Name                 string `json:"name"`
+	State                string `json:"state"`
+	DeviceTypeIdentifier string `json:"deviceTypeIdentifier"`
+	IsAvailable          bool   `json:"isAvailable"`
+	AvailabilityError    string `json:"availabilityError"`
+	DataPath             string `json:"dataPath"`
+	LogPath              string `json:"logPath"`
+	LastBootedAt         string `json:"lastBootedAt"`
+}
+
+// SimDevice is a simulator device as returned by ListDevices.
+type SimDevice struct {
+	UDID  string
+	Name  string
+	State string
+	// Runtime is the identifier of the runtime of the device, e.g.
+	// "com.apple.CoreSimulator.SimRuntime.iOS-18-2".
+	Runtime     string
+	IsAvailable bool
+}
+
+// SimctlPairDevice is one of the devices of a SimctlPair.
+type SimctlPairDevice struct {
+	UDID  string `json:"udid"`
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+// SimctlPair is a watch paired with a phone.
+type SimctlPair struct {
+	Watch SimctlPairDevice `json:"watch"`
+	Phone SimctlPairDevice `json:"phone"`
+	State string           `json:"state"`
+}
+
+// SimctlList runs "simctl list -j" and returns its parsed output.
+func (c *Client) SimctlList(ctx context.Context) (*SimctlListResult, error) {
+	out, err := c.Simctl(ctx, "list", "-j").Output()
+	if err != nil {
+		return nil, err
+	}
+	var result SimctlListResult
+	if err := json.Unmarshal(out, &result); err != nil {
+		return nil, fmt.Errorf("parse simctl list output: %w", err)
+	}
+	return &result, nil
+}
+
+// ListDevices runs "simctl list devices --json" and returns all devices, ordered by runtime.
+func (c *Client) ListDevices(ctx context.Context) ([]SimDevice, error) {
+	out, err := c.Simctl(ctx, "list", "devices", "--json").Output()
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Devices map[string][]SimctlDevice `json:"devices"`
+	}
+	if err := json.Unmarshal(out, &result); err != nil {
+		return nil, fmt.Errorf("parse simctl list output: %w", err)
+	}
+	runtimes := make([]string, 0, len(result.Devices))
+	for runtime := range result.Devices {
+		runtimes = append(runtimes, runtime)
+	}
+	slices.Sort(runtimes)
+	var devices []SimDevice
+	for _, runtime := range runtimes {
+		for _, d := range result.Devices[runtime] {
+			devices = append(devices, SimDevice{
+				UDID:        d.UDID,
+				Name:        d.Name,
+				State:       d.State,
+				Runtime:     runtime,
+				IsAvailable: d.IsAvailable,
+			})
+		}
+	}
+	return devices, nil
+}
@@ -0,0 +1,53 @@
+package ios
+
+import (
+	"context"
+	"fmt"
+)
+
+// BatteryState is the battery state shown in the status bar.
+type BatteryState string
+
+const (
+	BatteryCharging    BatteryState = "Charging"
+	BatteryCharged     BatteryState = "Charged"
+	BatteryDischarging BatteryState = "Discharging"
+)
+
+// StatusBarOptions holds the status bar values to override. Fields left empty or nil keep their
+// current value.
+type StatusBarOptions struct {
+	// Time is the text shown as the time, e.g. "9:41".
+	Time string `json:"time,omitempty"`
+	// BatteryLevel is the battery charge in percent, between 0 and 100.
+	BatteryLevel *int         `json:"batteryLevel,omitempty"`
+	BatteryState BatteryState `json:"batteryState,omitempty"`
+	// CellularBars is the number of cellular signal bars, between 0 and 4.
+	CellularBars *int `json:"cellularBars,omitempty"`
+	// WifiBars is the number of Wi-Fi signal bars, between 0 and 4.
+	WifiBars *int `json:"wifiBars,omitempty"`
+	// DataNetwork is the data network type, e.g. "wifi", "lte" or "5g".
+	DataNetwork string `json:"dataNetwork,omitempty"`
+}
+
+// SetStatusBar overrides the values shown in the status bar, like "simctl status_bar override",
+// so that screenshots are consistent between runs.
+func (c *Client) SetStatusBar(ctx context.Context, opts StatusBarOptions) error {
+	if opts.BatteryLevel != nil && (*opts.BatteryLevel < 0 || *opts.BatteryLevel > 100) {
+		return fmt.Errorf("battery level must be between 0 and 100, got %d", *opts.BatteryLevel)
+	}
+	if opts.CellularBars != nil && (*opts.CellularBars < 0 || *opts.CellularBars > 4) {
+		return fmt.Errorf("cellular bars must be between 0 and 4, got %d", *opts.CellularBars)
+	}
+	if opts.WifiBars != nil && (*opts.WifiBars < 0 || *opts.WifiBars > 4) {
+		return fmt.Errorf("wifi bars must be between 0 and 4, got %d", *opts.WifiBars)
+	}
+	_, err := c.sendRequest(ctx, &request{Type: "setStatusBar", StatusBar: &opts})
+	return err
+}
+
+// ClearStatusBar removes all overrides set with SetStatusBar.
+func (c *Client) ClearStatusBar(ctx context.Context) error {
+	_, err := c.sendRequest(ctx, &request{Type: "clearStatusBar"})
+	return err
+}
@@ -0,0 +1,38 @@
+package ios
+
+import "context"
+
+type traceIDKey struct{}
+
+// ContextWithTraceID returns a copy of ctx carrying the given trace ID. Requests sent with the
+// returned context include the trace ID so that server logs can be correlated with your traces.
+func ContextWithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey{}, traceID)
+}
+
+// TraceIDFromContext returns the trace ID set with ContextWithTraceID, if any.
+func TraceIDFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	traceID, ok := ctx.Value(traceIDKey{}).(string)
+	return traceID, ok && traceID != ""
+}
+
+// RequestTracer starts a span for every request sent by the client, see WithTracer.
+//
+// The client doesn't depend on a tracing library; implement RequestTracer to connect it to one.
+// For OpenTelemetry, use WithTracer of the websocket/ios/otelios module.
+type RequestTracer interface {
+	// StartRequest is called before a request is sent. The returned context is used to send the
+	// request, so a trace ID set on it with ContextWithTraceID is sent to the server. The returned
+	// function is called with the result of the request once it completes.
+	StartRequest(ctx context.Context, requestType, requestID string) (context.Context, func(err error))
+}
+
+// WithTracer makes the client report every request to the given tracer.
+func WithTracer(tracer RequestTracer) Option {
+	return func(c *Client) {
+		c.tracer = tracer
+	}
+}
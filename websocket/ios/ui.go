@@ -0,0 +1,60 @@
+package ios
+
+import (
+	"context"
+	"fmt"
+)
+
+// Appearance is the system-wide appearance of the simulator.
+type Appearance string
+
+const (
+	AppearanceLight Appearance = "Light"
+	AppearanceDark  Appearance = "Dark"
+)
+
+// SetAppearance switches the simulator to light or dark mode, like "simctl ui appearance".
+func (c *Client) SetAppearance(ctx context.Context, appearance Appearance) error {
+	switch appearance {
+	case AppearanceLight, AppearanceDark:
+	default:
+		return fmt.Errorf("invalid appearance %q", appearance)
+	}
+	_, err := c.sendRequest(ctx, &request{Type: "setAppearance", Appearance: appearance})
+	return err
+}
+
+// GetAppearance returns the current appearance of the simulator, e.g. to restore it after
+// a test changed it with SetAppearance.
+func (c *Client) GetAppearance(ctx context.Context) (Appearance, error) {
+	resp, err := c.sendRequest(ctx, &request{Type: "getAppearance"})
+	if err != nil {
+		return "", err
+	}
+	return resp.Appearance, nil
+}
+
+// ContentSizeCategory is a Dynamic Type text size.
+type ContentSizeCategory string
+
+const (
+	ContentSizeExtraSmall                        ContentSizeCategory = "ExtraSmall"
+	ContentSizeSmall                             ContentSizeCategory = "Small"
+	ContentSizeMedium                            ContentSizeCategory = "Medium"
+	ContentSizeLarge                             ContentSizeCategory = "Large"
+	ContentSizeExtraLarge                        ContentSizeCategory = "ExtraLarge"
+	ContentSizeExtraExtraLarge                   ContentSizeCategory = "ExtraExtraLarge"
+	ContentSizeExtraExtraExtraLarge              ContentSizeCategory = "ExtraExtraExtraLarge"
+	ContentSizeAccessibilityMedium               ContentSizeCategory = "AccessibilityMedium"
+	ContentSizeAccessibilityLarge                ContentSizeCategory = "AccessibilityLarge"
+	ContentSizeAccessibilityExtraLarge           ContentSizeCategory = "AccessibilityExtraLarge"
+	ContentSizeAccessibilityExtraExtraLarge      ContentSizeCategory = "AccessibilityExtraExtraLarge"
+	ContentSizeAccessibilityExtraExtraExtraLarge ContentSizeCategory = "AccessibilityExtraExtraExtraLarge"
+)
+
+// SetContentSize sets the preferred text size of the simulator, like "simctl ui content_size".
+// Use the accessibility categories to check that layouts hold up at the largest sizes.
+func (c *Client) SetContentSize(ctx context.Context, category ContentSizeCategory) error {
+	_, err := c.sendRequest(ctx, &request{Type: "setContentSize", ContentSize: category})
+	return err
+}
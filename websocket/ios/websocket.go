@@ -12,6 +12,7 @@ import (
 	"log/slog"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -20,12 +21,64 @@ import (
 	"github.com/gorilla/websocket"
 )
 
+const (
+	// defaultPingInterval is how often a ping is sent to keep the connection alive.
+	defaultPingInterval = 30 * time.Second
+	// defaultPingTimeout is the write deadline of a ping.
+	defaultPingTimeout = 10 * time.Second
+	// sendQueueSize is the number of messages that can be queued for sending before senders block.
+	sendQueueSize = 64
+	// defaultCloseTimeout is how long Close waits for the server to acknowledge the close handshake.
+	defaultCloseTimeout = time.Second
+	// closeWriteTimeout is the write deadline of the close message.
+	closeWriteTimeout = 500 * time.Millisecond
+)
+
 // Common errors returned by the client.
 var (
 	ErrNotConnected    = errors.New("websocket: not connected")
 	ErrConnectionClose = errors.New("websocket: connection closed")
+
+	// ErrDisconnected is returned for requests that were in flight when the connection dropped
+	// while WithAutoReconnect is used. The request may be retried once the client reconnects.
+	ErrDisconnected = errors.New("websocket: disconnected")
+
+	// ErrRequestTimeout is returned when the server doesn't reply within the timeout set with
+	// WithDefaultTimeout. It's distinct from the context errors so that it can be told apart
+	// from cancellation by the caller.
+	ErrRequestTimeout = errors.New("websocket: request timed out")
+
+	// ErrElementNotFound is returned when no element matches the selector of an element
+	// command such as TapElement.
+	ErrElementNotFound = errors.New("element not found")
+
+	// ErrInvalidScreenshot is returned when screenshot validation is enabled and the
+	// received image is corrupt.
+	ErrInvalidScreenshot = errors.New("invalid screenshot")
 )
 
+// CommandError is returned by client methods when a request fails. It identifies the request so
+// that the failure can be correlated with the server logs.
+type CommandError struct {
+	// RequestID is the ID the request was sent with.
+	RequestID string
+	// Type is the request type, e.g. "tap".
+	Type string
+	// Message is the error message returned by the server. It's empty if the request failed
+	// on the client side, e.g. because the connection was closed.
+	Message string
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *CommandError) Error() string {
+	return fmt.Sprintf("%s request %s: %v", e.Type, e.RequestID, e.Err)
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
 // AccessibilitySelector defines criteria for finding accessibility elements.
 // All non-empty fields must match for an element to be selected.
 type AccessibilitySelector struct {
@@ -36,6 +89,17 @@ type AccessibilitySelector struct {
 	Title           string `json:"title,omitempty"`
 	TitleContains   string `json:"titleContains,omitempty"`
 	Value           string `json:"value,omitempty"`
+	// Container, if set, restricts matching to descendants of the element it matches.
+	Container *AccessibilitySelector `json:"within,omitempty"`
+}
+
+// Within returns a copy of the selector that only matches descendants of the element matching
+// container, e.g. the "Buy" button of a specific card:
+//
+//	AccessibilitySelector{ElementType: "Button", Label: "Buy"}.Within(AccessibilitySelector{Label: "Card 3"})
+func (s AccessibilitySelector) Within(container AccessibilitySelector) AccessibilitySelector {
+	s.Container = &container
+	return s
 }
 
 // AccessibilityPoint represents a point on the screen.
@@ -69,16 +133,49 @@ type InstalledApp struct {
 	InstallType string `json:"installType"`
 }
 
+// AppInstallType is the installation type of an app as reported in InstalledApp.InstallType.
+type AppInstallType string
+
+const (
+	// AppInstallTypeUser matches apps installed by the user.
+	AppInstallTypeUser AppInstallType = "User"
+	// AppInstallTypeSystem matches apps that ship with the simulator runtime.
+	AppInstallTypeSystem AppInstallType = "System"
+)
+
+// AppFilter restricts the apps returned by ListAppsFiltered.
+// All non-empty fields must match for an app to be returned.
+type AppFilter struct {
+	InstallType  AppInstallType `json:"installType,omitempty"`
+	NameContains string         `json:"nameContains,omitempty"`
+}
+
 // LsofEntry represents an open file entry.
 type LsofEntry struct {
 	Kind string `json:"kind"`
 	Path string `json:"path"`
 }
 
+// DeviceInfo describes the simulated device.
+type DeviceInfo struct {
+	Model     string `json:"model"`
+	OSVersion string `json:"osVersion"`
+	Name      string `json:"name"`
+	UDID      string `json:"udid"`
+	// ScreenScale is the number of pixels per point.
+	ScreenScale float64 `json:"screenScale"`
+	// ScreenWidth and ScreenHeight are the screen dimensions in points.
+	ScreenWidth  float64 `json:"screenWidth"`
+	ScreenHeight float64 `json:"screenHeight"`
+}
+
 // AppInstallationResult contains the result of a successful app installation.
 type AppInstallationResult struct {
 	URL      string // The URL the app was installed from
 	BundleID string // Bundle ID of the installed app (always set on success)
+	// Err is only set by InstallAppStream when the installation fails.
+	// InstallApp returns the error directly instead.
+	Err error
 }
 
 // LaunchMode specifies how to launch an app after installation.
@@ -101,6 +198,19 @@ type AppInstallationOptions struct {
 	LaunchMode LaunchMode
 }
 
+// ServerCapabilities describes what the server the client is connected to supports.
+type ServerCapabilities struct {
+	// Version of the server.
+	Version string `json:"version"`
+	// Features lists the request types and features supported by the server, e.g. "recordVideo".
+	Features []string `json:"features"`
+}
+
+// Supports reports whether the server supports the given request type or feature.
+func (s ServerCapabilities) Supports(feature string) bool {
+	return slices.Contains(s.Features, feature)
+}
+
 // Option configures a Client.
 type Option func(*Client)
 
@@ -111,21 +221,202 @@ func WithLogger(logger *slog.Logger) Option {
 	}
 }
 
+// WithScreenshotValidation makes Screenshot decode every image it receives and verify that it's
+// a non-empty JPEG whose aspect ratio matches the reported dimensions. Corrupt payloads are
+// reported as ErrInvalidScreenshot instead of surfacing later when the image is used.
+func WithScreenshotValidation() Option {
+	return func(c *Client) {
+		c.validateScreenshots = true
+	}
+}
+
+// WithFairWrites makes goroutines hand their messages to the writer goroutine one at a time, in
+// the order they were submitted, instead of queueing them in a buffer, and makes them wait until
+// their message is written. This keeps tail latencies low when many goroutines share one client
+// and reports write errors to the caller.
+func WithFairWrites() Option {
+	return func(c *Client) {
+		c.fairWrites = true
+	}
+}
+
+// WithAutoReconnect makes the client re-dial the server when the connection drops unexpectedly.
+// Up to maxRetries attempts are made, waiting backoff before the first one and doubling the wait
+// after every failed attempt. If all attempts fail, the client is closed.
+//
+// Requests in flight when the connection drops fail with ErrDisconnected and can be retried.
+// Running simctl commands can't be resumed transparently; they fail with ErrConnectionClose,
+// see SimctlCmd.Resume to continue them.
+func WithAutoReconnect(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.reconnectRetries = maxRetries
+		c.reconnectBackoff = backoff
+	}
+}
+
+// WithPingInterval sets how often a ping is sent to keep the connection alive. Lower it if a
+// proxy in between closes idle connections sooner. Defaults to 30 seconds; a non-positive value
+// keeps the default.
+func WithPingInterval(d time.Duration) Option {
+	return func(c *Client) {
+		if d <= 0 {
+			d = defaultPingInterval
+		}
+		c.pingInterval = d
+	}
+}
+
+// WithPingTimeout sets the write deadline of a ping. Defaults to 10 seconds; a non-positive value
+// keeps the default.
+func WithPingTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		if d <= 0 {
+			d = defaultPingTimeout
+		}
+		c.pingTimeout = d
+	}
+}
+
+// WithDefaultTimeout sets how long a request waits for the server to reply when the context passed
+// to it has no deadline. Requests that time out fail with ErrRequestTimeout. A deadline set by the
+// caller always takes precedence. By default, requests wait until the context is done.
+func WithDefaultTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.defaultTimeout = d
+	}
+}
+
+// WithStateCallback sets a function that is called whenever the connection state changes. err is
+// the error that caused the change, if any. The callback is called synchronously from the client's
+// goroutines, so it should return quickly; it may call methods of the client.
+func WithStateCallback(fn func(state ConnState, err error)) Option {
+	return func(c *Client) {
+		c.onStateChange = fn
+	}
+}
+
+// WithDialHeader sets additional HTTP headers sent when dialing the server, e.g. cookies or
+// credentials required by a gateway in front of the instance. The token is still sent as a
+// query parameter.
+func WithDialHeader(header http.Header) Option {
+	return func(c *Client) {
+		c.dialHeader = header
+	}
+}
+
+// WithDialer sets the dialer used to connect to the server, e.g. to configure TLS, a proxy or a
+// handshake timeout. Defaults to websocket.DefaultDialer.
+func WithDialer(dialer *websocket.Dialer) Option {
+	return func(c *Client) {
+		c.dialer = dialer
+	}
+}
+
+// WithRetry makes the client retry failed requests up to attempts times in total, waiting backoff
+// before the first retry and doubling the wait after every further failure. Only requests that
+// fail with one of the retryOn errors are retried, as tested with errors.Is; by default these
+// are ErrElementNotFound, ErrRequestTimeout and ErrDisconnected. Other errors are returned
+// immediately, and no retry is made once the context is done.
+//
+// Retries apply to every request, so only use it if the commands you send are safe to repeat.
+// Queries like Screenshot, ElementTree or FindElement and commands with an absolute effect like
+// SetElementValue or SetOrientation are. Commands like TypeText, PressKey or IncrementElement
+// may take effect twice if a reply was lost after the server executed them.
+func WithRetry(attempts int, backoff time.Duration, retryOn ...error) Option {
+	return func(c *Client) {
+		c.retryAttempts = attempts
+		c.retryBackoff = backoff
+		c.retryOn = retryOn
+		if len(retryOn) == 0 {
+			c.retryOn = []error{ErrElementNotFound, ErrRequestTimeout, ErrDisconnected}
+		}
+	}
+}
+
+// CommandMetric describes a completed request, see WithMetrics.
+type CommandMetric struct {
+	// Type is the request type, e.g. "tap".
+	Type string
+	// RequestID is the ID the request was sent with.
+	RequestID string
+	// Duration is the time from sending the request until the response arrived or the
+	// request failed.
+	Duration time.Duration
+	// Err is the error the request failed with, or nil.
+	Err error
+}
+
+// WithMetrics sets a function that is called after every request with its type, latency and
+// error, e.g. to export them to Prometheus or OpenTelemetry. Retries made with WithRetry are
+// reported individually. The function is called synchronously from the requesting goroutine,
+// so it should return quickly.
+func WithMetrics(fn func(m CommandMetric)) Option {
+	return func(c *Client) {
+		c.onMetric = fn
+	}
+}
+
 // Client is a WebSocket client for interacting with a Limrun iOS instance.
 type Client struct {
 	apiURL string
 	token  string
 	logger *slog.Logger
 
+	validateScreenshots bool
+	fairWrites          bool
+	reconnectRetries    int
+	reconnectBackoff    time.Duration
+	pingInterval        time.Duration
+	pingTimeout         time.Duration
+	defaultTimeout      time.Duration
+	onStateChange       func(ConnState, error)
+	dialHeader          http.Header
+	dialer              *websocket.Dialer
+	retryAttempts       int
+	retryBackoff        time.Duration
+	retryOn             []error
+	onMetric            func(CommandMetric)
+	tracer              RequestTracer
+
 	ws               *websocket.Conn
+	readDone         chan struct{} // closed when the readLoop of ws returns
 	wsMu             sync.Mutex
-	pendingRequests  sync.Map // map[string]chan *response
-	simctlExecutions sync.Map // map[string]*SimctlCmd
+	writeQueue       chan writeOp // drained by writeLoop
+	pendingRequests  sync.Map     // map[string]chan *response
+	simctlExecutions sync.Map     // map[string]*SimctlCmd
+	logStreams       sync.Map     // map[string]*logStream
+	screenStreams    sync.Map     // map[string]*screenStream
 	requestID        atomic.Uint64
 	closed           atomic.Bool
+	readFailed       atomic.Bool // set when readLoop fails, cleared when reconnected
 	done             chan struct{}
 }
 
+// ConnState is the state of the connection to the server.
+type ConnState int
+
+const (
+	// StateConnected means the connection is established.
+	StateConnected ConnState = iota
+	// StateDisconnected means the connection was lost or closed and won't be re-established.
+	StateDisconnected
+	// StateReconnecting means the connection was lost and the client is re-dialing the server.
+	StateReconnecting
+)
+
+func (s ConnState) String() string {
+	switch s {
+	case StateConnected:
+		return "connected"
+	case StateDisconnected:
+		return "disconnected"
+	case StateReconnecting:
+		return "reconnecting"
+	default:
+		return fmt.Sprintf("ConnState(%d)", int(s))
+	}
+}
+
 // Orientation represents a device orientation.
 type Orientation string
 
@@ -136,10 +427,85 @@ const (
 	OrientationLandscape Orientation = "Landscape"
 )
 
+// AlertAction specifies how to handle a system alert.
+type AlertAction string
+
+const (
+	// AlertActionAccept taps the accepting button of the alert, e.g. "Allow".
+	AlertActionAccept AlertAction = "Accept"
+	// AlertActionDismiss taps the dismissing button of the alert, e.g. "Don't Allow".
+	AlertActionDismiss AlertAction = "Dismiss"
+)
+
+// AppRunState is the state of an app on the device.
+type AppRunState string
+
+const (
+	// AppStateNotInstalled means no app with the bundle identifier is installed.
+	AppStateNotInstalled AppRunState = "NotInstalled"
+	// AppStateNotRunning means the app is installed but not running.
+	AppStateNotRunning AppRunState = "NotRunning"
+	// AppStateBackground means the app is running in the background.
+	AppStateBackground AppRunState = "Background"
+	// AppStateForeground means the app is running in the foreground.
+	AppStateForeground AppRunState = "Foreground"
+)
+
+// HardwareButton is a physical button of the device.
+type HardwareButton string
+
+const (
+	// ButtonHome is the home button.
+	ButtonHome HardwareButton = "Home"
+	// ButtonLock is the side button that locks the device.
+	ButtonLock HardwareButton = "Lock"
+	// ButtonVolumeUp is the volume up button.
+	ButtonVolumeUp HardwareButton = "VolumeUp"
+	// ButtonVolumeDown is the volume down button.
+	ButtonVolumeDown HardwareButton = "VolumeDown"
+	// ButtonSiri activates Siri.
+	ButtonSiri HardwareButton = "Siri"
+)
+
+// BiometricKind is a biometric authentication method.
+type BiometricKind string
+
+const (
+	// BiometricFace is Face ID.
+	BiometricFace BiometricKind = "Face"
+	// BiometricTouch is Touch ID.
+	BiometricTouch BiometricKind = "Touch"
+)
+
+// PermissionService is a privacy-protected service an app needs permission to use.
+type PermissionService string
+
+const (
+	PermissionCamera        PermissionService = "Camera"
+	PermissionPhotos        PermissionService = "Photos"
+	PermissionLocation      PermissionService = "Location"
+	PermissionMicrophone    PermissionService = "Microphone"
+	PermissionContacts      PermissionService = "Contacts"
+	PermissionNotifications PermissionService = "Notifications"
+)
+
+// PermissionStatus is the permission state of an app for a PermissionService.
+type PermissionStatus string
+
+const (
+	// PermissionGrant allows the app to use the service without prompting.
+	PermissionGrant PermissionStatus = "Grant"
+	// PermissionDeny denies the app the use of the service without prompting.
+	PermissionDeny PermissionStatus = "Deny"
+	// PermissionUnset resets the permission so that the app prompts for it again.
+	PermissionUnset PermissionStatus = "Unset"
+)
+
 // request is an internal type for WebSocket requests.
 type request struct {
 	Type        string                 `json:"type"`
-	ID          string                 `json:"id"`
+	ID          string                 `json:"id,omitempty"`
+	TraceID     string                 `json:"traceId,omitempty"`
 	X           float64                `json:"x,omitempty"`
 	Y           float64                `json:"y,omitempty"`
 	Point       *AccessibilityPoint    `json:"point,omitempty"`
@@ -155,44 +521,115 @@ type request struct {
 	MD5         string                 `json:"md5,omitempty"`
 	LaunchMode  LaunchMode             `json:"launchMode,omitempty"`
 	Orientation Orientation            `json:"orientation,omitempty"`
+	Volume      *float64               `json:"volume,omitempty"`
+	StreamLogs  bool                   `json:"streamLogs,omitempty"`
+	Filter      *AppFilter             `json:"filter,omitempty"`
+	From        *AccessibilityPoint    `json:"from,omitempty"`
+	To          *AccessibilityPoint    `json:"to,omitempty"`
+	ToSelector  *AccessibilitySelector `json:"toSelector,omitempty"`
+	DurationMs  int64                  `json:"durationMs,omitempty"`
+	AlertAction AlertAction            `json:"alertAction,omitempty"`
+	Name        string                 `json:"name,omitempty"`
+	Path        string                 `json:"path,omitempty"`
+	Data        string                 `json:"data,omitempty"`
+	Button      HardwareButton         `json:"button,omitempty"`
+	Lat         *float64               `json:"lat,omitempty"`
+	Lon         *float64               `json:"lon,omitempty"`
+	Altitude    *float64               `json:"altitude,omitempty"`
+	Accuracy    *float64               `json:"horizontalAccuracy,omitempty"`
+	Payload     json.RawMessage        `json:"payload,omitempty"`
+	Biometric   BiometricKind          `json:"biometric,omitempty"`
+	Match       *bool                  `json:"match,omitempty"`
+	Enrolled    *bool                  `json:"enrolled,omitempty"`
+	Service     PermissionService      `json:"service,omitempty"`
+	Status      PermissionStatus       `json:"status,omitempty"`
+	StatusBar   *StatusBarOptions      `json:"statusBar,omitempty"`
+	Direction   ScrollDirection        `json:"direction,omitempty"`
+	MaxAttempts int                    `json:"maxAttempts,omitempty"`
+	Scale       float64                `json:"scale,omitempty"`
+	HoldBefore  int64                  `json:"holdBeforeMs,omitempty"`
+	HoldAfter   int64                  `json:"holdAfterMs,omitempty"`
+	Points      []AccessibilityPoint   `json:"points,omitempty"`
+	Taps        int                    `json:"taps,omitempty"`
+	FPS         int                    `json:"fps,omitempty"`
+	Quality     int                    `json:"quality,omitempty"`
+	Commands    []request              `json:"commands,omitempty"`
+	Appearance  Appearance             `json:"appearance,omitempty"`
+	ContentSize ContentSizeCategory    `json:"contentSize,omitempty"`
+	Steps       int                    `json:"steps,omitempty"`
+
+	// logs receives the log lines the server streams for this request, if set.
+	logs *logStream
 }
 
 // response is an internal type for handling WebSocket responses.
 type response struct {
-	Type         string          `json:"type"`
-	ID           string          `json:"id"`
-	Error        string          `json:"error,omitempty"`
-	Base64       string          `json:"base64,omitempty"`
-	Width        float64         `json:"width,omitempty"`
-	Height       float64         `json:"height,omitempty"`
-	JSON         string          `json:"json,omitempty"`
-	ElementLabel string          `json:"elementLabel,omitempty"`
-	ElementType  string          `json:"elementType,omitempty"`
-	Apps         string          `json:"apps,omitempty"`
-	Files        json.RawMessage `json:"files,omitempty"`
-	URL          string          `json:"url,omitempty"`
-	BundleID     string          `json:"bundleId,omitempty"`
+	Type         string                 `json:"type"`
+	ID           string                 `json:"id"`
+	Error        string                 `json:"error,omitempty"`
+	Base64       string                 `json:"base64,omitempty"`
+	Width        float64                `json:"width,omitempty"`
+	Height       float64                `json:"height,omitempty"`
+	JSON         string                 `json:"json,omitempty"`
+	ElementLabel string                 `json:"elementLabel,omitempty"`
+	ElementType  string                 `json:"elementType,omitempty"`
+	Apps         string                 `json:"apps,omitempty"`
+	Files        json.RawMessage        `json:"files,omitempty"`
+	URL          string                 `json:"url,omitempty"`
+	BundleID     string                 `json:"bundleId,omitempty"`
+	Volume       float64                `json:"volume,omitempty"`
+	Line         string                 `json:"line,omitempty"`
+	Handled      bool                   `json:"handled,omitempty"`
+	Version      string                 `json:"version,omitempty"`
+	Features     []string               `json:"features,omitempty"`
+	Element      *AccessibilityElement  `json:"element,omitempty"`
+	Elements     []AccessibilityElement `json:"elements,omitempty"`
+	Path         string                 `json:"path,omitempty"`
+	Text         string                 `json:"text,omitempty"`
+	State        AppRunState            `json:"state,omitempty"`
+	Device       json.RawMessage        `json:"device,omitempty"`
+	Timestamp    int64                  `json:"timestamp,omitempty"`
+	Results      []batchResult          `json:"results,omitempty"`
+	Appearance   Appearance             `json:"appearance,omitempty"`
+	Value        *string                `json:"value,omitempty"`
 	// simctlStream fields
+	//
+	// A simctl stream is a sequence of simctlStream messages with the ID of the simctl request,
+	// each carrying base64-encoded stdout and stderr chunks. The message with exitCode ends the
+	// stream unless it sets done to false; the stream then continues with more output and ends
+	// with a message with done set to true, which may carry the last chunk too. Servers that
+	// send output after the exit code must use done; the client drops output that arrives after
+	// the stream ended.
 	Stdout   string `json:"stdout,omitempty"`
 	Stderr   string `json:"stderr,omitempty"`
 	ExitCode *int   `json:"exitCode,omitempty"`
+	Done     *bool  `json:"done,omitempty"`
 }
 
 // NewClient creates a new WebSocket client and connects to the given API URL.
 func NewClient(apiURL, token string, opts ...Option) (*Client, error) {
 	c := &Client{
-		apiURL: apiURL,
-		token:  token,
-		logger: slog.Default(),
-		done:   make(chan struct{}),
+		apiURL:       apiURL,
+		token:        token,
+		logger:       slog.Default(),
+		pingInterval: defaultPingInterval,
+		pingTimeout:  defaultPingTimeout,
+		done:         make(chan struct{}),
 	}
 	for _, opt := range opts {
 		opt(c)
 	}
+	if c.fairWrites {
+		c.writeQueue = make(chan writeOp)
+	} else {
+		c.writeQueue = make(chan writeOp, sendQueueSize)
+	}
 
 	if err := c.connect(); err != nil {
 		return nil, err
 	}
+	go c.writeLoop()
+	go c.pingLoop()
 	return c, nil
 }
 
@@ -208,57 +645,229 @@ func (c *Client) connect() error {
 	q.Set("token", c.token)
 	u.RawQuery = q.Encode()
 
-	ws, _, err := websocket.DefaultDialer.Dial(u.String(), http.Header{})
+	dialer := c.dialer
+	if dialer == nil {
+		dialer = websocket.DefaultDialer
+	}
+	ws, _, err := dialer.Dial(u.String(), c.dialHeader.Clone())
 	if err != nil {
 		return fmt.Errorf("websocket dial: %w", err)
 	}
 
+	readDone := make(chan struct{})
 	c.wsMu.Lock()
+	if c.closed.Load() {
+		// The client was closed while dialing; CloseContext already dealt with the previous
+		// connection, so this one must not be installed or read from.
+		c.wsMu.Unlock()
+		_ = ws.Close()
+		return ErrConnectionClose
+	}
 	c.ws = ws
+	c.readDone = readDone
 	c.wsMu.Unlock()
 
-	go c.readLoop()
-	go c.pingLoop()
+	c.readFailed.Store(false)
+	go func() {
+		defer close(readDone)
+		c.readLoop(ws)
+	}()
 
+	c.setState(StateConnected, nil)
 	return nil
 }
 
-// Close closes the WebSocket connection and releases resources.
-func (c *Client) Close() error {
-	if c.closed.Swap(true) {
-		return nil // Already closed
+// setState reports a state change to the callback set with WithStateCallback.
+// It must not be called while holding wsMu.
+func (c *Client) setState(state ConnState, err error) {
+	if c.onStateChange != nil {
+		c.onStateChange(state, err)
 	}
-	close(c.done)
+}
 
+// reconnect fails everything in flight on the dropped connection and re-dials with exponential
+// backoff. The client is closed if all attempts fail.
+func (c *Client) reconnect() {
+	c.failInFlight()
 	c.wsMu.Lock()
-	err := c.ws.Close()
+	_ = c.ws.Close()
 	c.wsMu.Unlock()
 
-	// Fail all pending requests
-	c.pendingRequests.Range(func(key, value any) bool {
-		close(value.(chan *response))
-		c.pendingRequests.Delete(key)
+	delay := c.reconnectBackoff
+	for attempt := 1; attempt <= c.reconnectRetries; attempt++ {
+		select {
+		case <-c.done:
+			return
+		case <-time.After(delay):
+		}
+		err := c.connect()
+		if err == nil {
+			c.logger.Info("websocket reconnected", "attempt", attempt)
+			return
+		}
+		if errors.Is(err, ErrConnectionClose) {
+			return
+		}
+		c.logger.Warn("websocket reconnect failed", "attempt", attempt, "error", err)
+		delay *= 2
+	}
+	c.logger.Error("websocket reconnect attempts exhausted", "attempts", c.reconnectRetries)
+	_ = c.Close()
+}
+
+// failInFlight fails all pending requests and simctl executions.
+func (c *Client) failInFlight() {
+	c.pendingRequests.Range(func(key, _ any) bool {
+		// LoadAndDelete makes sure a channel is closed only once and never after readLoop
+		// picked it up to deliver a response.
+		if value, ok := c.pendingRequests.LoadAndDelete(key); ok {
+			close(value.(chan *response))
+		}
 		return true
 	})
-
-	// Fail all simctl executions
 	c.simctlExecutions.Range(func(key, value any) bool {
 		cmd := value.(*SimctlCmd)
 		cmd.handleError(ErrConnectionClose)
 		c.simctlExecutions.Delete(key)
 		return true
 	})
+	c.screenStreams.Range(func(key, value any) bool {
+		value.(*screenStream).close()
+		c.screenStreams.Delete(key)
+		return true
+	})
+}
 
+// writeOp is a message waiting to be sent by writeLoop.
+type writeOp struct {
+	messageType int
+	data        []byte
+	deadline    time.Time // only used for control messages
+	err         chan error
+}
+
+// queueMessage queues a text message to be sent over the WebSocket connection by writeLoop.
+// Messages queued by a single goroutine are sent in order. If errCh isn't nil, it must be
+// buffered and receives the result of the write; otherwise a failed write is only logged.
+//
+// With WithFairWrites, the queue is unbuffered, so blocked senders are served in FIFO order.
+func (c *Client) queueMessage(data []byte, errCh chan error) error {
+	select {
+	case c.writeQueue <- writeOp{messageType: websocket.TextMessage, data: data, err: errCh}:
+		return nil
+	case <-c.done:
+		return ErrConnectionClose
+	}
+}
+
+// writeMessage queues a text message like queueMessage and returns without waiting for it to be
+// written. With WithFairWrites, it waits until the message is written and returns the write
+// error.
+func (c *Client) writeMessage(data []byte) error {
+	if !c.fairWrites {
+		return c.queueMessage(data, nil)
+	}
+	errCh := make(chan error, 1)
+	if err := c.queueMessage(data, errCh); err != nil {
+		return err
+	}
+	select {
+	case err := <-errCh:
+		return err
+	case <-c.done:
+		return ErrConnectionClose
+	}
+}
+
+func (c *Client) writeLoop() {
+	for {
+		select {
+		case <-c.done:
+			return
+		case op := <-c.writeQueue:
+			var err error
+			c.wsMu.Lock()
+			if op.messageType == websocket.TextMessage {
+				err = c.ws.WriteMessage(op.messageType, op.data)
+			} else {
+				err = c.ws.WriteControl(op.messageType, op.data, op.deadline)
+			}
+			c.wsMu.Unlock()
+			if op.err != nil {
+				op.err <- err
+			} else if err != nil {
+				c.logger.Error("websocket write error", "error", err)
+			}
+		}
+	}
+}
+
+// IsConnected reports whether the connection is up. It returns false once the client is closed
+// or the connection dropped, until WithAutoReconnect re-establishes it. No request is sent.
+func (c *Client) IsConnected() bool {
+	return !c.closed.Load() && !c.readFailed.Load()
+}
+
+// Close closes the WebSocket connection and releases resources. It waits up to a second for the
+// server to acknowledge the close handshake, see CloseContext.
+func (c *Client) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultCloseTimeout)
+	defer cancel()
+	return c.CloseContext(ctx)
+}
+
+// CloseContext closes the WebSocket connection gracefully: it sends a close message and waits
+// until the server acknowledges it or ctx is done, then closes the connection and releases
+// resources. Requests in flight fail with ErrConnectionClose.
+func (c *Client) CloseContext(ctx context.Context) error {
+	if c.closed.Swap(true) {
+		return nil // Already closed
+	}
+	close(c.done)
+
+	// The close message is only a courtesy so that the server sees a normal closure; if it can't
+	// be written in time, the connection is closed anyway.
+	deadline := time.Now().Add(closeWriteTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	c.wsMu.Lock()
+	ws, readDone := c.ws, c.readDone
+	err := ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), deadline)
+	c.wsMu.Unlock()
+	if err != nil {
+		c.logger.Debug("failed to send close message", "error", err)
+	} else {
+		select {
+		case <-readDone:
+		case <-ctx.Done():
+		}
+	}
+
+	err = ws.Close()
+
+	c.failInFlight()
+
+	c.setState(StateDisconnected, nil)
 	return err
 }
 
-func (c *Client) readLoop() {
+func (c *Client) readLoop(ws *websocket.Conn) {
 	for {
-		_, message, err := c.ws.ReadMessage()
+		_, message, err := ws.ReadMessage()
 		if err != nil {
-			if !c.closed.Load() {
-				c.logger.Error("websocket read error", "error", err)
+			if c.closed.Load() {
+				return
+			}
+			c.readFailed.Store(true)
+			c.logger.Error("websocket read error", "error", err)
+			if c.reconnectRetries > 0 {
+				c.setState(StateReconnecting, err)
+				c.reconnect()
+				return
 			}
+			c.failInFlight()
+			c.setState(StateDisconnected, err)
 			return
 		}
 
@@ -279,10 +888,21 @@ func (c *Client) readLoop() {
 				if resp.Stderr != "" {
 					stderr, _ = base64.StdEncoding.DecodeString(resp.Stderr)
 				}
-				cmd.handleOutput(stdout, stderr, resp.ExitCode)
-				if resp.ExitCode != nil {
-					c.simctlExecutions.Delete(resp.ID)
-				}
+				cmd.handleOutput(stdout, stderr, resp.ExitCode, resp.Done)
+			}
+			continue
+		}
+
+		if resp.Type == "screenFrame" {
+			if val, ok := c.screenStreams.Load(resp.ID); ok {
+				val.(*screenStream).handleFrame(&resp)
+			}
+			continue
+		}
+
+		if resp.Type == "log" {
+			if val, ok := c.logStreams.Load(resp.ID); ok {
+				val.(*logStream).send(resp.Line)
 			}
 			continue
 		}
@@ -294,7 +914,7 @@ func (c *Client) readLoop() {
 }
 
 func (c *Client) pingLoop() {
-	ticker := time.NewTicker(30 * time.Second)
+	ticker := time.NewTicker(c.pingInterval)
 	defer ticker.Stop()
 
 	for {
@@ -302,48 +922,162 @@ func (c *Client) pingLoop() {
 		case <-c.done:
 			return
 		case <-ticker.C:
-			c.wsMu.Lock()
-			_ = c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second))
-			c.wsMu.Unlock()
+			op := writeOp{messageType: websocket.PingMessage, deadline: time.Now().Add(c.pingTimeout)}
+			select {
+			case c.writeQueue <- op:
+			case <-c.done:
+				return
+			}
 		}
 	}
 }
 
+// isElementNotFound reports whether a server error message means that no element matched the
+// selector of the request.
+func isElementNotFound(message string) bool {
+	message = strings.ToLower(message)
+	return strings.Contains(message, "element not found") || strings.Contains(message, "no element")
+}
+
+// serverError returns the error for a server error message, wrapping ErrElementNotFound if it
+// means that no element matched the selector.
+func serverError(message string) error {
+	if isElementNotFound(message) {
+		return fmt.Errorf("%w: %s", ErrElementNotFound, message)
+	}
+	return errors.New(message)
+}
+
+// nextRequestID returns a new unique ID for a request or simctl execution.
+func (c *Client) nextRequestID() string {
+	return fmt.Sprintf("go-%d-%d", time.Now().UnixNano(), c.requestID.Add(1))
+}
+
+// sendRequest sends the request and waits for its response, retrying as configured with WithRetry.
 func (c *Client) sendRequest(ctx context.Context, req *request) (*response, error) {
+	id := req.ID
+	delay := c.retryBackoff
+	for attempt := 1; ; attempt++ {
+		req.ID = id
+		resp, err := c.roundTrip(ctx, req)
+		if err == nil || attempt >= c.retryAttempts || !c.isRetryable(err) {
+			return resp, err
+		}
+		c.logger.Debug("retrying request", "type", req.Type, "attempt", attempt, "error", err)
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// isRetryable reports whether a failed request should be retried.
+func (c *Client) isRetryable(err error) bool {
+	for _, target := range c.retryOn {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// roundTrip sends the request once and waits for its response.
+func (c *Client) roundTrip(ctx context.Context, req *request) (_ *response, err error) {
 	if c.closed.Load() {
 		return nil, ErrNotConnected
 	}
 
-	req.ID = fmt.Sprintf("go-%d-%d", time.Now().UnixNano(), c.requestID.Add(1))
+	if req.ID == "" {
+		req.ID = c.nextRequestID()
+	}
+	if c.onMetric != nil {
+		start := time.Now()
+		defer func() {
+			c.onMetric(CommandMetric{Type: req.Type, RequestID: req.ID, Duration: time.Since(start), Err: err})
+		}()
+	}
+	if c.tracer != nil {
+		var end func(error)
+		ctx, end = c.tracer.StartRequest(ctx, req.Type, req.ID)
+		defer func() {
+			end(err)
+		}()
+	}
+	var serverMessage string
+	defer func() {
+		if err != nil {
+			err = &CommandError{RequestID: req.ID, Type: req.Type, Message: serverMessage, Err: err}
+		}
+	}()
+	req.TraceID, _ = TraceIDFromContext(ctx)
 	respCh := make(chan *response, 1)
 	c.pendingRequests.Store(req.ID, respCh)
 	defer c.pendingRequests.Delete(req.ID)
+	if req.logs != nil {
+		c.logStreams.Store(req.ID, req.logs)
+		defer c.logStreams.Delete(req.ID)
+	}
 
 	data, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("marshal request: %w", err)
 	}
 
-	c.logger.Debug("sending request", "type", req.Type, "id", req.ID)
+	c.logger.Debug("sending request", "type", req.Type, "id", req.ID, "traceId", req.TraceID)
 
-	c.wsMu.Lock()
-	err = c.ws.WriteMessage(websocket.TextMessage, data)
-	c.wsMu.Unlock()
-	if err != nil {
+	// The write result is awaited together with the response so that a failed write fails the
+	// request right away instead of leaving it waiting for a response that never comes.
+	writeErr := make(chan error, 1)
+	if err := c.queueMessage(data, writeErr); err != nil {
 		return nil, fmt.Errorf("send request: %w", err)
 	}
 
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case resp, ok := <-respCh:
-		if !ok {
-			return nil, ErrConnectionClose
+	var timeout <-chan time.Time
+	if _, ok := ctx.Deadline(); !ok && c.defaultTimeout > 0 {
+		timer := time.NewTimer(c.defaultTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	if c.fairWrites {
+		select {
+		case err := <-writeErr:
+			if err != nil {
+				return nil, fmt.Errorf("send request: %w", err)
+			}
+			writeErr = nil
+		case <-c.done:
+			return nil, fmt.Errorf("send request: %w", ErrConnectionClose)
 		}
-		if resp.Error != "" {
-			return nil, errors.New(resp.Error)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timeout:
+			return nil, fmt.Errorf("no reply after %s: %w", c.defaultTimeout, ErrRequestTimeout)
+		case err := <-writeErr:
+			if err != nil {
+				return nil, fmt.Errorf("send request: %w", err)
+			}
+			writeErr = nil // A nil channel blocks, so the response is awaited alone from now on.
+		case resp, ok := <-respCh:
+			if !ok {
+				if !c.closed.Load() && c.reconnectRetries > 0 {
+					return nil, ErrDisconnected
+				}
+				return nil, ErrConnectionClose
+			}
+			if resp.Error != "" {
+				// The response is returned too since it may carry partial results, e.g. of a batch.
+				serverMessage = resp.Error
+				return resp, serverError(resp.Error)
+			}
+			return resp, nil
 		}
-		return resp, nil
 	}
 }
 
@@ -351,17 +1085,78 @@ func (c *Client) sendRequest(ctx context.Context, req *request) (*response, erro
 // Client Methods
 // ============================================================================
 
+// Capabilities returns the version of the server and the features it supports. Use it to check
+// whether an operation is available before attempting it on servers of different versions.
+func (c *Client) Capabilities(ctx context.Context) (ServerCapabilities, error) {
+	resp, err := c.sendRequest(ctx, &request{Type: "capabilities"})
+	if err != nil {
+		return ServerCapabilities{}, err
+	}
+	return ServerCapabilities{
+		Version:  resp.Version,
+		Features: resp.Features,
+	}, nil
+}
+
+// Ping checks that the server is responsive without side effects on the instance. Servers that
+// don't support it reply with an error, which is returned as is.
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.sendRequest(ctx, &request{Type: "ping"})
+	return err
+}
+
+// DeviceInfo returns the model, OS version and screen dimensions of the device. Multiply point
+// coordinates by ScreenScale to get pixel coordinates.
+func (c *Client) DeviceInfo(ctx context.Context) (*DeviceInfo, error) {
+	resp, err := c.sendRequest(ctx, &request{Type: "deviceInfo"})
+	if err != nil {
+		return nil, err
+	}
+	var info DeviceInfo
+	if err := json.Unmarshal(resp.Device, &info); err != nil {
+		return nil, fmt.Errorf("parse device info: %w", err)
+	}
+	return &info, nil
+}
+
 // Screenshot takes a screenshot of the current simulator screen.
 func (c *Client) Screenshot(ctx context.Context) (*ScreenshotData, error) {
 	resp, err := c.sendRequest(ctx, &request{Type: "screenshot"})
 	if err != nil {
 		return nil, err
 	}
-	return &ScreenshotData{
+	data := &ScreenshotData{
 		Base64: resp.Base64,
 		Width:  resp.Width,
 		Height: resp.Height,
-	}, nil
+	}
+	if c.validateScreenshots {
+		if err := validateScreenshot(data); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// Snapshot takes a screenshot and returns the accessibility hierarchy of the screen in a single
+// request. Both are captured together on the server, so the element tree always describes the
+// returned screenshot even while the UI is animating.
+func (c *Client) Snapshot(ctx context.Context) (*ScreenshotData, string, error) {
+	resp, err := c.sendRequest(ctx, &request{Type: "snapshot"})
+	if err != nil {
+		return nil, "", err
+	}
+	data := &ScreenshotData{
+		Base64: resp.Base64,
+		Width:  resp.Width,
+		Height: resp.Height,
+	}
+	if c.validateScreenshots {
+		if err := validateScreenshot(data); err != nil {
+			return nil, "", err
+		}
+	}
+	return data, resp.JSON, nil
 }
 
 // ElementTree returns the accessibility hierarchy of the current screen.
@@ -391,6 +1186,112 @@ func (c *Client) TapElement(ctx context.Context, selector AccessibilitySelector)
 	}, nil
 }
 
+// MultiTap taps all given points simultaneously with one finger each, taps times in a row,
+// e.g. two points for a two-finger tap.
+func (c *Client) MultiTap(ctx context.Context, points []AccessibilityPoint, taps int) error {
+	if len(points) == 0 {
+		return errors.New("multi tap needs at least one point")
+	}
+	if taps < 1 {
+		return fmt.Errorf("multi tap count must be at least 1, got %d", taps)
+	}
+	_, err := c.sendRequest(ctx, &request{Type: "multiTap", Points: points, Taps: taps})
+	return err
+}
+
+// DoubleTap simulates a double tap at the specified coordinates.
+func (c *Client) DoubleTap(ctx context.Context, x, y float64) error {
+	_, err := c.sendRequest(ctx, &request{Type: "doubleTap", X: x, Y: y})
+	return err
+}
+
+// DoubleTapElement double taps an accessibility element matching the selector.
+func (c *Client) DoubleTapElement(ctx context.Context, selector AccessibilitySelector) (*TapElementResult, error) {
+	resp, err := c.sendRequest(ctx, &request{Type: "doubleTapElement", Selector: &selector})
+	if err != nil {
+		return nil, err
+	}
+	return &TapElementResult{
+		ElementLabel: resp.ElementLabel,
+		ElementType:  resp.ElementType,
+	}, nil
+}
+
+// LongPress presses and holds at the specified coordinates for the given duration.
+func (c *Client) LongPress(ctx context.Context, x, y float64, duration time.Duration) error {
+	_, err := c.sendRequest(ctx, &request{Type: "longPress", X: x, Y: y, DurationMs: duration.Milliseconds()})
+	return err
+}
+
+// LongPressElement presses and holds an accessibility element matching the selector for the given duration.
+func (c *Client) LongPressElement(ctx context.Context, selector AccessibilitySelector, duration time.Duration) (*TapElementResult, error) {
+	resp, err := c.sendRequest(ctx, &request{Type: "longPressElement", Selector: &selector, DurationMs: duration.Milliseconds()})
+	if err != nil {
+		return nil, err
+	}
+	return &TapElementResult{
+		ElementLabel: resp.ElementLabel,
+		ElementType:  resp.ElementType,
+	}, nil
+}
+
+// Pinch performs a two-finger pinch centered at the given coordinates, taking the given duration.
+// A scale greater than 1 zooms in and a scale less than 1 zooms out.
+func (c *Client) Pinch(ctx context.Context, centerX, centerY, scale float64, duration time.Duration) error {
+	if scale <= 0 {
+		return fmt.Errorf("pinch scale must be positive, got %v", scale)
+	}
+	_, err := c.sendRequest(ctx, &request{
+		Type:       "pinch",
+		X:          centerX,
+		Y:          centerY,
+		Scale:      scale,
+		DurationMs: duration.Milliseconds(),
+	})
+	return err
+}
+
+// Drag presses at the given start coordinates, holds for holdBefore to pick up the item, moves to
+// the end coordinates and holds for holdAfter before releasing. Unlike a swipe, this is suitable
+// for drag and drop, e.g. to reorder list items.
+func (c *Client) Drag(ctx context.Context, fromX, fromY, toX, toY float64, holdBefore, holdAfter time.Duration) error {
+	if fromX < 0 || fromY < 0 || toX < 0 || toY < 0 {
+		return fmt.Errorf("drag coordinates must not be negative, got (%v, %v) to (%v, %v)", fromX, fromY, toX, toY)
+	}
+	_, err := c.sendRequest(ctx, &request{
+		Type:       "drag",
+		From:       &AccessibilityPoint{X: fromX, Y: fromY},
+		To:         &AccessibilityPoint{X: toX, Y: toY},
+		HoldBefore: holdBefore.Milliseconds(),
+		HoldAfter:  holdAfter.Milliseconds(),
+	})
+	return err
+}
+
+// DragElementToPoint drags the element matching the selector to the given coordinates,
+// taking the given duration for the movement.
+func (c *Client) DragElementToPoint(ctx context.Context, from AccessibilitySelector, toX, toY float64, duration time.Duration) error {
+	_, err := c.sendRequest(ctx, &request{
+		Type:       "drag",
+		Selector:   &from,
+		To:         &AccessibilityPoint{X: toX, Y: toY},
+		DurationMs: duration.Milliseconds(),
+	})
+	return err
+}
+
+// DragFromPointToElement drags from the given coordinates onto the element matching the selector,
+// taking the given duration for the movement.
+func (c *Client) DragFromPointToElement(ctx context.Context, fromX, fromY float64, to AccessibilitySelector, duration time.Duration) error {
+	_, err := c.sendRequest(ctx, &request{
+		Type:       "drag",
+		From:       &AccessibilityPoint{X: fromX, Y: fromY},
+		ToSelector: &to,
+		DurationMs: duration.Milliseconds(),
+	})
+	return err
+}
+
 // IncrementElement increments an accessibility element (useful for sliders, steppers).
 func (c *Client) IncrementElement(ctx context.Context, selector AccessibilitySelector) (*ElementResult, error) {
 	resp, err := c.sendRequest(ctx, &request{Type: "incrementElement", Selector: &selector})
@@ -409,6 +1310,46 @@ func (c *Client) DecrementElement(ctx context.Context, selector AccessibilitySel
 	return &ElementResult{ElementLabel: resp.ElementLabel}, nil
 }
 
+// GetElementValue returns the current value of the element matching the selector, e.g. the
+// position of a slider or the text of a text field. It returns an error wrapping
+// ErrElementNotFound if no element matches, so that an empty value can be told apart.
+func (c *Client) GetElementValue(ctx context.Context, selector AccessibilitySelector) (string, error) {
+	resp, err := c.sendRequest(ctx, &request{Type: "getElementValue", Selector: &selector})
+	if err != nil {
+		return "", err
+	}
+	if resp.Value == nil {
+		return "", fmt.Errorf("%w: no element matches selector %+v", ErrElementNotFound, selector)
+	}
+	return *resp.Value, nil
+}
+
+// IncrementElementBy increments an accessibility element the given number of times in a
+// single request.
+func (c *Client) IncrementElementBy(ctx context.Context, selector AccessibilitySelector, steps int) (*ElementResult, error) {
+	if steps < 1 {
+		return nil, fmt.Errorf("steps must be at least 1, got %d", steps)
+	}
+	resp, err := c.sendRequest(ctx, &request{Type: "incrementElement", Selector: &selector, Steps: steps})
+	if err != nil {
+		return nil, err
+	}
+	return &ElementResult{ElementLabel: resp.ElementLabel}, nil
+}
+
+// DecrementElementBy decrements an accessibility element the given number of times in a
+// single request.
+func (c *Client) DecrementElementBy(ctx context.Context, selector AccessibilitySelector, steps int) (*ElementResult, error) {
+	if steps < 1 {
+		return nil, fmt.Errorf("steps must be at least 1, got %d", steps)
+	}
+	resp, err := c.sendRequest(ctx, &request{Type: "decrementElement", Selector: &selector, Steps: steps})
+	if err != nil {
+		return nil, err
+	}
+	return &ElementResult{ElementLabel: resp.ElementLabel}, nil
+}
+
 // SetElementValue sets the value of an accessibility element.
 func (c *Client) SetElementValue(ctx context.Context, text string, selector AccessibilitySelector) (*ElementResult, error) {
 	resp, err := c.sendRequest(ctx, &request{Type: "setElementValue", Text: text, Selector: &selector})
@@ -418,27 +1359,100 @@ func (c *Client) SetElementValue(ctx context.Context, text string, selector Acce
 	return &ElementResult{ElementLabel: resp.ElementLabel}, nil
 }
 
+// ClearElement deletes the contents of the text field matching the selector, e.g. before
+// entering new text with TypeTextInElement.
+func (c *Client) ClearElement(ctx context.Context, selector AccessibilitySelector) (*ElementResult, error) {
+	resp, err := c.sendRequest(ctx, &request{Type: "clearElement", Selector: &selector})
+	if err != nil {
+		return nil, err
+	}
+	return &ElementResult{ElementLabel: resp.ElementLabel}, nil
+}
+
+// TypeTextInElement focuses the element matching the selector and types text into it, optionally
+// pressing Enter afterwards. Both happen in a single request, so focus can't move to another
+// element in between as it could with TapElement followed by TypeText.
+func (c *Client) TypeTextInElement(ctx context.Context, text string, selector AccessibilitySelector, pressEnter bool) (*ElementResult, error) {
+	resp, err := c.sendRequest(ctx, &request{Type: "typeTextInElement", Text: text, Selector: &selector, PressEnter: pressEnter})
+	if err != nil {
+		return nil, err
+	}
+	return &ElementResult{ElementLabel: resp.ElementLabel}, nil
+}
+
 // TypeText types text into the currently focused input field.
 func (c *Client) TypeText(ctx context.Context, text string, pressEnter bool) error {
 	_, err := c.sendRequest(ctx, &request{Type: "typeText", Text: text, PressEnter: pressEnter})
 	return err
 }
 
+// DismissKeyboard hides the software keyboard. It succeeds without doing anything if no keyboard
+// is visible.
+func (c *Client) DismissKeyboard(ctx context.Context) error {
+	_, err := c.sendRequest(ctx, &request{Type: "dismissKeyboard"})
+	return err
+}
+
 // PressKey presses a key on the keyboard, optionally with modifiers.
 func (c *Client) PressKey(ctx context.Context, key string, modifiers ...string) error {
 	_, err := c.sendRequest(ctx, &request{Type: "pressKey", Key: key, Modifiers: modifiers})
 	return err
 }
 
+// PressHardwareButton presses a physical button of the device, e.g. to lock the screen or send
+// the app to the background.
+func (c *Client) PressHardwareButton(ctx context.Context, button HardwareButton) error {
+	_, err := c.sendRequest(ctx, &request{Type: "pressButton", Button: button})
+	return err
+}
+
+// Shake simulates shaking the device, which some apps use to show debug menus or undo prompts.
+func (c *Client) Shake(ctx context.Context) error {
+	_, err := c.sendRequest(ctx, &request{Type: "shake"})
+	return err
+}
+
 // LaunchApp launches an installed app by bundle identifier.
 func (c *Client) LaunchApp(ctx context.Context, bundleID string) error {
 	_, err := c.sendRequest(ctx, &request{Type: "launchApp", BundleID: bundleID})
 	return err
 }
 
+// TerminateApp stops a running app by bundle identifier.
+func (c *Client) TerminateApp(ctx context.Context, bundleID string) error {
+	_, err := c.sendRequest(ctx, &request{Type: "terminateApp", BundleID: bundleID})
+	return err
+}
+
+// AppState returns whether the app with the given bundle identifier is installed and running.
+// An unknown bundle identifier results in AppStateNotInstalled rather than an error.
+func (c *Client) AppState(ctx context.Context, bundleID string) (AppRunState, error) {
+	resp, err := c.sendRequest(ctx, &request{Type: "appState", BundleID: bundleID})
+	if err != nil {
+		return "", err
+	}
+	return resp.State, nil
+}
+
+// UninstallApp removes an installed app and its data by bundle identifier.
+func (c *Client) UninstallApp(ctx context.Context, bundleID string) error {
+	_, err := c.sendRequest(ctx, &request{Type: "uninstallApp", BundleID: bundleID})
+	return err
+}
+
 // ListApps returns a list of installed apps on the simulator.
 func (c *Client) ListApps(ctx context.Context) ([]InstalledApp, error) {
-	resp, err := c.sendRequest(ctx, &request{Type: "listApps"})
+	return c.listApps(ctx, &request{Type: "listApps"})
+}
+
+// ListAppsFiltered returns the installed apps matching the filter. Filtering is done on the
+// server so only the matching apps are transferred.
+func (c *Client) ListAppsFiltered(ctx context.Context, filter AppFilter) ([]InstalledApp, error) {
+	return c.listApps(ctx, &request{Type: "listApps", Filter: &filter})
+}
+
+func (c *Client) listApps(ctx context.Context, req *request) ([]InstalledApp, error) {
+	resp, err := c.sendRequest(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -455,15 +1469,34 @@ func (c *Client) OpenURL(ctx context.Context, urlStr string) error {
 	return err
 }
 
+// OpenDeepLink opens a link with a custom URL scheme, e.g. "myapp://orders/42", in the app that
+// registered the scheme and returns the link and the bundle ID of that app. Unlike OpenURL, it
+// returns an error if no app handles the link instead of falling back to Safari.
+func (c *Client) OpenDeepLink(ctx context.Context, link string) (*AppInstallationResult, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil, fmt.Errorf("invalid deep link: %w", err)
+	}
+	if u.Scheme == "" {
+		return nil, fmt.Errorf("invalid deep link %q: missing scheme", link)
+	}
+	resp, err := c.sendRequest(ctx, &request{Type: "openDeepLink", URL: link})
+	if err != nil {
+		return nil, err
+	}
+	if resp.BundleID == "" {
+		return nil, fmt.Errorf("no app handles the %q scheme", u.Scheme)
+	}
+	return &AppInstallationResult{
+		URL:      link,
+		BundleID: resp.BundleID,
+	}, nil
+}
+
 // InstallApp installs an app from a URL (supports .ipa or .app files, optionally zipped).
 // Returns the installation result with bundle ID on success.
 func (c *Client) InstallApp(ctx context.Context, urlStr string, opts *AppInstallationOptions) (*AppInstallationResult, error) {
-	req := &request{Type: "appInstallation", URL: urlStr}
-	if opts != nil {
-		req.MD5 = opts.MD5
-		req.LaunchMode = opts.LaunchMode
-	}
-	resp, err := c.sendRequest(ctx, req)
+	resp, err := c.sendRequest(ctx, newAppInstallationRequest(urlStr, opts))
 	if err != nil {
 		return nil, err
 	}
@@ -473,6 +1506,72 @@ func (c *Client) InstallApp(ctx context.Context, urlStr string, opts *AppInstall
 	}, nil
 }
 
+// InstallAppStream installs an app like InstallApp while streaming the server-side installation
+// log lines as they're produced, which helps diagnosing code-signing and provisioning failures.
+//
+// The log channel is closed once the installation finishes. The result channel then receives
+// exactly one result and is closed; check its Err field to see whether the installation failed.
+// Log lines are dropped if the log channel isn't drained fast enough.
+func (c *Client) InstallAppStream(ctx context.Context, urlStr string, opts *AppInstallationOptions) (<-chan string, <-chan *AppInstallationResult) {
+	logs := &logStream{ch: make(chan string, 256)}
+	results := make(chan *AppInstallationResult, 1)
+	req := newAppInstallationRequest(urlStr, opts)
+	req.StreamLogs = true
+	req.logs = logs
+	go func() {
+		defer close(results)
+		resp, err := c.sendRequest(ctx, req)
+		logs.close()
+		if err != nil {
+			results <- &AppInstallationResult{URL: urlStr, Err: err}
+			return
+		}
+		results <- &AppInstallationResult{
+			URL:      resp.URL,
+			BundleID: resp.BundleID,
+		}
+	}()
+	return logs.ch, results
+}
+
+func newAppInstallationRequest(urlStr string, opts *AppInstallationOptions) *request {
+	req := &request{Type: "appInstallation", URL: urlStr}
+	if opts != nil {
+		req.MD5 = opts.MD5
+		req.LaunchMode = opts.LaunchMode
+	}
+	return req
+}
+
+// logStream delivers log lines streamed by the server for a single request.
+// Sends never block the read loop and are ignored once the stream is closed.
+type logStream struct {
+	mu     sync.Mutex
+	ch     chan string
+	closed bool
+}
+
+func (s *logStream) send(line string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.ch <- line:
+	default:
+	}
+}
+
+func (s *logStream) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+}
+
 // Lsof lists open Unix sockets on the instance.
 func (c *Client) Lsof(ctx context.Context) ([]LsofEntry, error) {
 	resp, err := c.sendRequest(ctx, &request{Type: "listOpenFiles", Kind: "unix"})
@@ -493,6 +1592,103 @@ func (c *Client) SetOrientation(ctx context.Context, orientation Orientation) er
 	return err
 }
 
+// GetVolume returns the current output volume of the simulator, between 0.0 and 1.0.
+func (c *Client) GetVolume(ctx context.Context) (float64, error) {
+	resp, err := c.sendRequest(ctx, &request{Type: "getVolume"})
+	if err != nil {
+		return 0, err
+	}
+	return resp.Volume, nil
+}
+
+// SetVolume sets the output volume of the simulator. The level must be between 0.0 and 1.0.
+func (c *Client) SetVolume(ctx context.Context, level float64) error {
+	if level < 0 || level > 1 {
+		return fmt.Errorf("volume level must be between 0.0 and 1.0, got %v", level)
+	}
+	_, err := c.sendRequest(ctx, &request{Type: "setVolume", Volume: &level})
+	return err
+}
+
+// SetSimctlEnv sets a device-wide environment variable using launchctl setenv.
+// The variable is visible to every app and process launched afterwards, so there's
+// no need to pass it to each launch separately.
+func (c *Client) SetSimctlEnv(ctx context.Context, key, value string) error {
+	if key == "" {
+		return errors.New("environment variable key cannot be empty")
+	}
+	out, err := c.Simctl(ctx, "spawn", "booted", "launchctl", "setenv", key, value).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("launchctl setenv %s: %w: %s", key, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// HandleSystemAlert accepts or dismisses the system alert currently on screen, such as location
+// or notification permission prompts. It reports whether an alert was present and handled;
+// no alert being present is not an error, so it's safe to call before every step.
+func (c *Client) HandleSystemAlert(ctx context.Context, action AlertAction) (bool, error) {
+	resp, err := c.sendRequest(ctx, &request{Type: "handleSystemAlert", AlertAction: action})
+	if err != nil {
+		return false, err
+	}
+	return resp.Handled, nil
+}
+
+// SetPermission grants, denies or resets the permission of an app to use a privacy-protected
+// service, like "simctl privacy". Granting permissions up front avoids system prompts during
+// tests.
+func (c *Client) SetPermission(ctx context.Context, bundleID string, service PermissionService, status PermissionStatus) error {
+	_, err := c.sendRequest(ctx, &request{Type: "setPermission", BundleID: bundleID, Service: service, Status: status})
+	return err
+}
+
+// SendPushNotification delivers a simulated push notification to the app with the given bundle
+// identifier, like "simctl push". The payload is an APNs JSON payload and must contain an "aps"
+// key, e.g. {"aps": {"alert": "Hello"}}.
+func (c *Client) SendPushNotification(ctx context.Context, bundleID string, payload json.RawMessage) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return fmt.Errorf("invalid push notification payload: %w", err)
+	}
+	if _, ok := fields["aps"]; !ok {
+		return errors.New(`invalid push notification payload: missing "aps" key`)
+	}
+	_, err := c.sendRequest(ctx, &request{Type: "pushNotification", BundleID: bundleID, Payload: payload})
+	return err
+}
+
+// Biometric simulates a Face ID or Touch ID scan in response to an authentication prompt.
+// match determines whether the scan succeeds or fails. The biometric kind must be enrolled,
+// see EnrollBiometric.
+func (c *Client) Biometric(ctx context.Context, kind BiometricKind, match bool) error {
+	_, err := c.sendRequest(ctx, &request{Type: "biometric", Biometric: kind, Match: &match})
+	return err
+}
+
+// EnrollBiometric sets whether Face ID or Touch ID is enrolled on the device.
+func (c *Client) EnrollBiometric(ctx context.Context, kind BiometricKind, enrolled bool) error {
+	_, err := c.sendRequest(ctx, &request{Type: "enrollBiometric", Biometric: kind, Enrolled: &enrolled})
+	return err
+}
+
+// SetClipboard sets the content of the simulator's pasteboard to the given text.
+// Only plain text is supported.
+func (c *Client) SetClipboard(ctx context.Context, text string) error {
+	_, err := c.sendRequest(ctx, &request{Type: "setClipboard", Text: text})
+	return err
+}
+
+// GetClipboard returns the text content of the simulator's pasteboard.
+// Only plain text is supported; other content is returned as an empty string.
+func (c *Client) GetClipboard(ctx context.Context) (string, error) {
+	resp, err := c.sendRequest(ctx, &request{Type: "getClipboard"})
+	if err != nil {
+		return "", err
+	}
+	return resp.Text, nil
+}
+
 // Simctl creates a new SimctlCmd to run the given simctl arguments.
 // The provided context is used to kill the process (by calling Kill)
 // if the context becomes done before the command completes on its own.
@@ -519,6 +1715,9 @@ func (c *Client) SetOrientation(ctx context.Context, orientation Orientation) er
 //	cmd.Start()
 //	io.Copy(os.Stdout, stdout)
 //	cmd.Wait()
+//
+// Wait returns once the server ends the output stream of the command, which it does with the
+// exit code unless it marks that more output follows.
 func (c *Client) Simctl(ctx context.Context, args ...string) *SimctlCmd {
 	return &SimctlCmd{
 		Args:   args,
@@ -0,0 +1,395 @@
+package ios
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// newEchoServer starts a server that acknowledges every request with an empty response.
+func newEchoServer(tb testing.TB) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		for {
+			_, message, err := ws.ReadMessage()
+			if err != nil {
+				return
+			}
+			var req request
+			if err := json.Unmarshal(message, &req); err != nil {
+				return
+			}
+			data, _ := json.Marshal(response{Type: req.Type + "Result", ID: req.ID})
+			if err := ws.WriteMessage(websocket.TextMessage, data); err != nil {
+				return
+			}
+		}
+	}))
+	tb.Cleanup(srv.Close)
+	return srv
+}
+
+func benchmarkConcurrentRequests(b *testing.B, opts ...Option) {
+	srv := newEchoServer(b)
+	c, err := NewClient(srv.URL, "token", opts...)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer c.Close()
+
+	var mu sync.Mutex
+	latencies := make([]time.Duration, 0, b.N)
+	b.SetParallelism(16)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			start := time.Now()
+			if err := c.Tap(context.Background(), 1, 1); err != nil {
+				b.Error(err)
+				return
+			}
+			elapsed := time.Since(start)
+			mu.Lock()
+			latencies = append(latencies, elapsed)
+			mu.Unlock()
+		}
+	})
+	b.StopTimer()
+
+	slices.Sort(latencies)
+	if len(latencies) > 0 {
+		b.ReportMetric(float64(latencies[len(latencies)/2].Microseconds()), "p50-µs")
+		b.ReportMetric(float64(latencies[len(latencies)*99/100].Microseconds()), "p99-µs")
+		b.ReportMetric(float64(latencies[len(latencies)-1].Microseconds()), "max-µs")
+	}
+}
+
+// BenchmarkConcurrentRequests compares the latency distribution of requests sent from many
+// goroutines with and without WithFairWrites.
+func BenchmarkConcurrentRequests(b *testing.B) {
+	b.Run("default", func(b *testing.B) {
+		benchmarkConcurrentRequests(b)
+	})
+	b.Run("fair", func(b *testing.B) {
+		benchmarkConcurrentRequests(b, WithFairWrites())
+	})
+}
+
+// failingConn is a connection whose writes fail once failWrites is set while reads keep working.
+type failingConn struct {
+	net.Conn
+	failWrites *atomic.Bool
+}
+
+var errWriteFailed = errors.New("write failed")
+
+func (c failingConn) Write(b []byte) (int, error) {
+	if c.failWrites.Load() {
+		return 0, errWriteFailed
+	}
+	return c.Conn.Write(b)
+}
+
+// TestWriteErrorFailsRequest makes sure that a request whose message can't be written fails with
+// the write error instead of waiting for a response that never comes.
+func TestWriteErrorFailsRequest(t *testing.T) {
+	for name, opts := range map[string][]Option{
+		"default": nil,
+		"fair":    {WithFairWrites()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			srv := newEchoServer(t)
+			var failWrites atomic.Bool
+			dialer := &websocket.Dialer{
+				NetDialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+					conn, err := (&net.Dialer{}).DialContext(ctx, network, addr)
+					if err != nil {
+						return nil, err
+					}
+					return failingConn{Conn: conn, failWrites: &failWrites}, nil
+				},
+			}
+			c, err := NewClient(srv.URL, "token", append(opts, WithDialer(dialer))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+
+			failWrites.Store(true)
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- c.Tap(context.Background(), 1, 1)
+			}()
+			select {
+			case err := <-errCh:
+				if !errors.Is(err, errWriteFailed) {
+					t.Fatalf("expected the write error, got %v", err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("request didn't fail after its write failed")
+			}
+		})
+	}
+}
+
+// newSimctlServer starts a server that answers every simctl request with the given messages.
+func newSimctlServer(t *testing.T, messages ...response) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		for {
+			_, message, err := ws.ReadMessage()
+			if err != nil {
+				return
+			}
+			var req request
+			if err := json.Unmarshal(message, &req); err != nil {
+				return
+			}
+			if req.Type != "simctl" {
+				continue
+			}
+			for _, msg := range messages {
+				msg.Type = "simctlStream"
+				msg.ID = req.ID
+				data, _ := json.Marshal(msg)
+				if err := ws.WriteMessage(websocket.TextMessage, data); err != nil {
+					return
+				}
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSimctlOutputAroundExitCode(t *testing.T) {
+	exitCode := 0
+	notDone, done := false, true
+	chunk := func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	}
+	tests := map[string][]response{
+		"exit code with last chunk": {
+			{Stdout: chunk("a")},
+			{Stdout: chunk("b"), ExitCode: &exitCode},
+		},
+		"separate exit code message": {
+			{Stdout: chunk("a")},
+			{Stdout: chunk("b")},
+			{ExitCode: &exitCode},
+		},
+		"output after exit code": {
+			{Stdout: chunk("a"), ExitCode: &exitCode, Done: &notDone},
+			{Stdout: chunk("b")},
+			{Done: &done},
+		},
+	}
+	for name, messages := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv := newSimctlServer(t, messages...)
+			c, err := NewClient(srv.URL, "token")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			out, err := c.Simctl(ctx, "list").Output()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != "ab" {
+				t.Fatalf("expected output %q, got %q", "ab", out)
+			}
+		})
+	}
+}
+
+// TestCloseDuringReconnect makes sure that a connection dialed while the client is being closed
+// is dropped instead of reported as connected.
+func TestCloseDuringReconnect(t *testing.T) {
+	srv := newEchoServer(t)
+	var (
+		dials    atomic.Int32
+		first    net.Conn
+		dialing  = make(chan struct{})
+		release  = make(chan struct{})
+		statesMu sync.Mutex
+		states   []ConnState
+	)
+	dialer := &websocket.Dialer{
+		NetDialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			if dials.Add(1) > 1 {
+				close(dialing)
+				<-release
+			}
+			conn, err := (&net.Dialer{}).DialContext(ctx, network, addr)
+			if err == nil && first == nil {
+				first = conn
+			}
+			return conn, err
+		},
+	}
+	c, err := NewClient(srv.URL, "token",
+		WithDialer(dialer),
+		WithAutoReconnect(1, 10*time.Millisecond),
+		WithStateCallback(func(state ConnState, _ error) {
+			statesMu.Lock()
+			defer statesMu.Unlock()
+			states = append(states, state)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Drop the connection and close the client while the reconnect is dialing.
+	_ = first.Close()
+	<-dialing
+	if err := c.Close(); err != nil {
+		t.Logf("close: %v", err)
+	}
+	statesMu.Lock()
+	closedAt := len(states)
+	statesMu.Unlock()
+	close(release)
+	time.Sleep(200 * time.Millisecond)
+
+	statesMu.Lock()
+	defer statesMu.Unlock()
+	for _, state := range states[closedAt:] {
+		if state == StateConnected {
+			t.Fatalf("client reported %v after it was closed", state)
+		}
+	}
+}
+
+// TestBatchPartialResults makes sure that a failed batch returns the results of the commands
+// that succeeded before the failing one, and the error of that command.
+func TestBatchPartialResults(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		_, message, err := ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		var req map[string]any
+		if err := json.Unmarshal(message, &req); err != nil {
+			return
+		}
+		for _, cmd := range req["commands"].([]any) {
+			if _, ok := cmd.(map[string]any)["id"]; ok {
+				t.Errorf("batch command sent with an id: %s", message)
+			}
+		}
+		data, _ := json.Marshal(response{
+			Type:  "batchResult",
+			ID:    req["id"].(string),
+			Error: "element not found",
+			Results: []batchResult{
+				{BatchResult: BatchResult{Type: "tap"}},
+				{BatchResult: BatchResult{Type: "tapElement"}, Error: "element not found"},
+			},
+		})
+		_ = ws.WriteMessage(websocket.TextMessage, data)
+		_, _, _ = ws.ReadMessage()
+	}))
+	defer srv.Close()
+	c, err := NewClient(srv.URL, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	results, err := c.Batch(context.Background(), TapCmd(1, 1), TapElementCmd(AccessibilitySelector{Label: "OK"}), TapCmd(2, 2))
+	if len(results) != 1 || results[0].Type != "tap" {
+		t.Fatalf("expected the result of the first command, got %+v", results)
+	}
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) || cmdErr.Type != "tapElement" || !errors.Is(err, ErrElementNotFound) {
+		t.Fatalf("expected an element not found error of the second command, got %v", err)
+	}
+}
+
+// TestScreenStreamDropsOldestFrames makes sure that a slow receiver gets the most recent frames.
+func TestScreenStreamDropsOldestFrames(t *testing.T) {
+	s := newScreenStream()
+	const frames = screenFrameBuffer + 3
+	for i := 1; i <= frames; i++ {
+		s.handleFrame(&response{Base64: base64.StdEncoding.EncodeToString([]byte{byte(i)}), Timestamp: int64(i)})
+	}
+	s.close()
+	want := int64(frames - screenFrameBuffer + 1)
+	for frame := range s.ch {
+		if got := frame.Timestamp.UnixMilli(); got != want {
+			t.Fatalf("expected frame %d, got %d", want, got)
+		}
+		want++
+	}
+	if want != frames+1 {
+		t.Fatalf("expected the last %d frames, the last one received was %d", screenFrameBuffer, want-1)
+	}
+}
+
+// TestSimctlOutputOverflow makes sure that a command whose output isn't read fails instead of
+// buffering output without bounds.
+func TestSimctlOutputOverflow(t *testing.T) {
+	chunk := base64.StdEncoding.EncodeToString(make([]byte, 1<<20))
+	messages := make([]response, maxQueuedOutput>>20+2)
+	for i := range messages {
+		messages[i].Stdout = chunk
+	}
+	srv := newSimctlServer(t, messages...)
+	c, err := NewClient(srv.URL, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	cmd := c.Simctl(context.Background(), "spawn", "booted", "log", "stream")
+	if _, err := cmd.StdoutPipe(); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- cmd.Wait()
+	}()
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, ErrOutputOverflow) {
+			t.Fatalf("expected ErrOutputOverflow, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("command didn't fail when its output wasn't read")
+	}
+}